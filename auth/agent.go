@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	agentOnce   sync.Once
+	agentClient agent.ExtendedAgent
+	agentErr    error
+)
+
+// AgentSocket 返回 ssh-agent 的 socket 路径(SSH_AUTH_SOCK)
+func AgentSocket() string {
+	return os.Getenv("SSH_AUTH_SOCK")
+}
+
+// Agent 返回连接到 SSH_AUTH_SOCK 的 ssh-agent 客户端，整个进程共用同一个连接
+func Agent() (agent.ExtendedAgent, error) {
+	agentOnce.Do(func() {
+		socket := AgentSocket()
+		if socket == "" {
+			agentErr = fmt.Errorf("SSH_AUTH_SOCK is not set, ssh-agent is not running")
+			return
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			agentErr = fmt.Errorf("failed to connect to ssh-agent: %w", err)
+			return
+		}
+		agentClient = agent.NewClient(conn)
+	})
+	return agentClient, agentErr
+}
+
+// LoadAgentSigners 返回 ssh-agent 中可用于认证的签名器
+// identity 不为空时只提供匹配的身份，避免 agent 中密钥过多导致 "too many authentication failures"
+func LoadAgentSigners(identity string) ([]ssh.Signer, error) {
+	ag, err := Agent()
+	if err != nil {
+		return nil, err
+	}
+	return AgentSigners(ag, identity)
+}
+
+// AgentSigners 返回 agent 中的签名器
+// identity 可以是密钥注释、SHA256 指纹(SHA256:...)或公钥文件路径，为空时返回全部
+func AgentSigners(ag agent.Agent, identity string) ([]ssh.Signer, error) {
+	signers, err := ag.Signers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ssh-agent keys: %w", err)
+	}
+	if identity == "" {
+		return signers, nil
+	}
+
+	wanted := make(map[string]bool)
+	if pubBytes, err := os.ReadFile(identity); err == nil {
+		if pub, _, _, _, err := ssh.ParseAuthorizedKey(pubBytes); err == nil {
+			wanted[string(pub.Marshal())] = true
+		}
+	}
+	keys, err := ag.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ssh-agent keys: %w", err)
+	}
+	for _, key := range keys {
+		if key.Comment == identity || ssh.FingerprintSHA256(key) == identity {
+			wanted[string(key.Marshal())] = true
+		}
+	}
+
+	var selected []ssh.Signer
+	for _, signer := range signers {
+		if wanted[string(signer.PublicKey().Marshal())] {
+			selected = append(selected, signer)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no ssh-agent key matches identity '%s'", identity)
+	}
+	return selected, nil
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestAgentSigners(t *testing.T) {
+	keyring := agent.NewKeyring()
+	var pubs []ssh.PublicKey
+	for _, comment := range []string{"work@laptop", "personal@laptop", "deploy"} {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+		assert.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: priv, Comment: comment}))
+		signer, err := ssh.NewSignerFromKey(priv)
+		assert.NoError(t, err)
+		pubs = append(pubs, signer.PublicKey())
+	}
+
+	// 不指定身份时返回全部
+	signers, err := AgentSigners(keyring, "")
+	assert.NoError(t, err)
+	assert.Len(t, signers, 3)
+
+	// 按注释选择
+	signers, err = AgentSigners(keyring, "personal@laptop")
+	assert.NoError(t, err)
+	if assert.Len(t, signers, 1) {
+		assert.Equal(t, pubs[1].Marshal(), signers[0].PublicKey().Marshal())
+	}
+
+	// 按指纹选择
+	signers, err = AgentSigners(keyring, ssh.FingerprintSHA256(pubs[2]))
+	assert.NoError(t, err)
+	if assert.Len(t, signers, 1) {
+		assert.Equal(t, pubs[2].Marshal(), signers[0].PublicKey().Marshal())
+	}
+
+	// 按公钥文件选择
+	pubFile := filepath.Join(t.TempDir(), "id_ed25519.pub")
+	assert.NoError(t, os.WriteFile(pubFile, ssh.MarshalAuthorizedKey(pubs[0]), 0644))
+	signers, err = AgentSigners(keyring, pubFile)
+	assert.NoError(t, err)
+	if assert.Len(t, signers, 1) {
+		assert.Equal(t, pubs[0].Marshal(), signers[0].PublicKey().Marshal())
+	}
+
+	// 没有匹配的身份
+	_, err = AgentSigners(keyring, "missing")
+	assert.Error(t, err)
+}
@@ -9,11 +9,9 @@
 package auth
 
 import (
-	"net"
 	"os"
 
 	"golang.org/x/crypto/ssh"
-	"golang.org/x/crypto/ssh/agent"
 )
 
 // PrivateKey Loads a private and public key from "path" and returns a SSH ClientConfig to authenticate with the server
@@ -64,13 +62,11 @@ func PrivateKeyWithPassphrase(user, keyPath string, passpharase []byte, keyCallB
 
 // Creates a configuration for a client that fetches public-private key from the SSH agent for authentication
 func SshAgent(user string, keyCallBack ssh.HostKeyCallback) (ssh.ClientConfig, error) {
-	socket := os.Getenv("SSH_AUTH_SOCK")
-	conn, err := net.Dial("unix", socket)
+	agentClient, err := Agent()
 	if err != nil {
 		return ssh.ClientConfig{}, err
 	}
 
-	agentClient := agent.NewClient(conn)
 	return ssh.ClientConfig{
 		User: user,
 		Auth: []ssh.AuthMethod{
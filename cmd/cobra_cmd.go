@@ -23,14 +23,26 @@ type cfgKeyType string
 
 const cfgKey cfgKeyType = "cfg"
 
+// tagsKey context 中节点菜单的标签选择表达式(*config.Selector)
+const tagsKey cfgKeyType = "tags"
+
 // rootCmd 代表没有调用子命令时的基础命令
 var rootCmd = &cobra.Command{
-	Use:     "mysshw",
+	Use:     "mysshw [node | [user@]host[:port] | ssh://[user@]host[:port]]",
 	Version: Version,
 	Short:   "CLI mysshw: A free and open source SSH command line client software.",
 	Long: `CLI mysshw: A free and open source SSH command line client software.
 
+Without arguments the node menu is shown, limited by --tags to the nodes whose
+tags match a selector such as "prod && !mysql". With a node name or alias, the
+node is connected directly; a partial name is matched fuzzily and a choice is
+offered when several nodes match. "user@host:port" and ssh:// URLs use the matching
+node's settings when the host is a configured node, and connect ad hoc to hosts
+that are not in the config.
+
 Use "mysshw help" for more information about a specific command.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeNodes,
 	Run: func(cmd *cobra.Command, args []string) {
 		// 检查是否请求版本信息
 		versionFlag, _ := cmd.Flags().GetBool("version")
@@ -49,12 +61,40 @@ Use "mysshw help" for more information about a specific command.`,
 				cfgKey, cfgPath))
 		}
 
+		// 节点菜单只显示标签符合 --tags 的节点
+		sel, err := tagsSelector(cmd)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		cmd.SetContext(context.WithValue(cmd.Context(), tagsKey, sel))
+
+		// 指定了节点或主机时直接连接
+		if len(args) > 0 {
+			if err := connectTarget(cmd, args[0]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		// 当没有子命令时执行 RunSSH
 		RunSSH(cmd.Context())
 	},
 	Example: `  # Connect to SSH using default configuration
   mysshw
 
+  # Only show the nodes tagged prod but not mysql in the menu
+  mysshw --tags 'prod && !mysql'
+
+  # List the nodes tagged k8s-worker
+  mysshw ls --tags k8s-worker
+
+  # Connect to a node by name or alias, a partial name, or a host not in the config
+  mysshw prod-db
+  mysshw root@10.0.0.5:2222
+  mysshw ssh://deploy@example.com
+
   # Connect using a custom configuration file
   mysshw --cfg /path/to/custom/config.toml
   or
@@ -76,6 +116,40 @@ Use "mysshw help" for more information about a specific command.`,
   or
   mysshw yml --file ~/.sshw.yml
 
+  # Import hosts from ~/.ssh/config
+  mysshw import ssh-config
+
+  # Export all nodes as OpenSSH config Host blocks
+  mysshw export ssh-config -o ~/.ssh/config.d/mysshw
+
+  # Forward local port 5432 to the database behind a node
+  mysshw forward prod-db -L 5432:127.0.0.1:5432
+
+  # Expose local port 3000 as port 8000 on a node
+  mysshw forward dev-box -R 8000:127.0.0.1:3000
+
+  # Run a SOCKS5 proxy through a node
+  mysshw socks bastion --listen 127.0.0.1:1080
+
+  # Run a command on a node and exit with its exit status
+  mysshw exec prod-db -- uptime
+
+  # Run a command on every node of a group concurrently
+  mysshw exec --group Groups01 --parallel 10 -- uptime
+
+  # Check that every node of a group is reachable and accepts its credentials
+  mysshw ping --group Groups01 --auth
+
+  # Show the 50 most recent connections
+  mysshw history -n 50
+
+  # Play back a recorded session at double speed
+  mysshw replay --speed 2 ~/.mysshw/recordings/prod-db-20250101-120000.cast
+
+  # List or close shared connections ([control] enable = true)
+  mysshw control ls
+  mysshw control stop
+
   # Display version information
   mysshw version | -v | --version
 
@@ -90,11 +164,24 @@ func init() {
 	rootCmd.PersistentFlags().StringP("cfg", "c", "", "Custom config file path (default is $HOME/.mysshw.toml)")
 
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "Print version for mysshw")
+	rootCmd.Flags().String("tags", "", "Only show the nodes whose tags match this selector in the menu, e.g. 'prod && !mysql'")
+	// 错误由 Execute 统一输出，避免重复打印
+	rootCmd.SilenceErrors = true
 
 	// 添加子命令
 	rootCmd.AddCommand(syncCmd)
 	rootCmd.AddCommand(VersionCmd)
 	rootCmd.AddCommand(YMLCmd)
+	rootCmd.AddCommand(forwardCmd)
+	rootCmd.AddCommand(socksCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(controlCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(pingCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(lsCmd)
 
 	// 为 sync 命令添加标志
 	syncCmd.Flags().BoolP("upload", "u", false, "Upload local config to remote server")
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"mysshw/config"
+	"mysshw/ssh"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// connectTarget 直接连接命令行上指定的节点或主机，会话结束后返回
+func connectTarget(cmd *cobra.Command, target string) error {
+	if err := loadCmdConfig(cmd); err != nil {
+		return err
+	}
+	node, err := resolveTarget(target)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		fmt.Println(ConnectCancelledStr)
+		return nil
+	}
+	start := time.Now()
+	if ssh.NewClient(node).Login(nil) {
+		recordHistory(node, start)
+	}
+	return nil
+}
+
+// resolveTarget 将命令行参数解析为要连接的节点，依次尝试:
+// 节点名称或别名；user@host:port 中的 host 是节点名称、别名或主机地址；按名称与别名模糊匹配(IP 地址除外)；
+// 都不匹配时作为不在配置中的临时主机连接。用户取消选择时返回 nil, nil
+func resolveTarget(target string) (*config.SSHNode, error) {
+	if !strings.HasPrefix(target, "ssh://") {
+		if node := config.CFG.FindNode(target); node != nil {
+			return node, nil
+		}
+	}
+	parsed, err := config.ParseSSHTarget(target)
+	if err != nil {
+		return nil, fmt.Errorf("mysshw:: %v", err)
+	}
+
+	// 指定了用户或端口时沿用节点的其他配置(密钥、跳板等)
+	if node := config.CFG.FindNode(parsed.Host); node != nil {
+		return overrideNode(node, parsed), nil
+	}
+	if node := findNodeByAddress(parsed); node != nil {
+		return overrideNode(node, parsed), nil
+	}
+
+	if parsed.User == "" && parsed.Port == 0 && !strings.HasPrefix(target, "ssh://") && net.ParseIP(target) == nil {
+		candidates := fuzzyFindNodes(target)
+		switch {
+		case len(candidates) == 1:
+			fmt.Fprintf(os.Stderr, ConnectFuzzyStr, target, candidates[0].Name)
+			return candidates[0], nil
+		case len(candidates) > 1:
+			return chooseCandidate(target, candidates)
+		}
+	}
+
+	// 临时主机使用默认身份文件与 ssh-agent，其余设置与配置中的节点默认值相同
+	parsed.UseAgent = os.Getenv("SSH_AUTH_SOCK") != ""
+	fmt.Fprintf(os.Stderr, ConnectAdHocStr, target, parsed.SetUser(), parsed.Host, parsed.SetPort())
+	return parsed, nil
+}
+
+// overrideNode 返回节点的副本，使用 parsed 中指定的用户和端口，节点组的设置仍然生效
+func overrideNode(node, parsed *config.SSHNode) *config.SSHNode {
+	if parsed.User == "" && parsed.Port == 0 {
+		return node
+	}
+	n := node.Copy()
+	if parsed.User != "" {
+		n.User = parsed.User
+	}
+	if parsed.Port != 0 {
+		n.Port = parsed.Port
+	}
+	return n
+}
+
+// findNodeByAddress 按主机地址查找节点，指定了用户或端口时也要相同；有多个节点符合时返回nil
+func findNodeByAddress(parsed *config.SSHNode) *config.SSHNode {
+	var found *config.SSHNode
+	for _, group := range config.CFG.Nodes {
+		for _, node := range group.SSHNodes {
+			if node.Host != parsed.Host ||
+				(parsed.User != "" && node.SetUser() != parsed.User) ||
+				(parsed.Port != 0 && node.SetPort() != parsed.Port) {
+				continue
+			}
+			if found != nil {
+				return nil
+			}
+			found = node
+		}
+	}
+	return found
+}
+
+// fuzzyFindNodes 按名称与别名模糊匹配节点，按匹配分数从高到低排列
+func fuzzyFindNodes(query string) []*config.SSHNode {
+	type candidate struct {
+		node  *config.SSHNode
+		score int
+	}
+	var candidates []candidate
+	for _, group := range config.CFG.Nodes {
+		for _, node := range group.SSHNodes {
+			best, matched := 0, false
+			for _, text := range []string{node.Name, node.Alias} {
+				if text == "" {
+					continue
+				}
+				if score, _, ok := ssh.FuzzyMatch(query, text); ok && (!matched || score > best) {
+					best, matched = score, true
+				}
+			}
+			if matched {
+				candidates = append(candidates, candidate{node, best})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	nodes := make([]*config.SSHNode, len(candidates))
+	for i, c := range candidates {
+		nodes[i] = c.node
+	}
+	return nodes
+}
+
+// chooseCandidate 有多个节点匹配时让用户选择，不在终端中运行时返回错误
+func chooseCandidate(query string, nodes []*config.SSHNode) (*config.SSHNode, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		names := make([]string, len(nodes))
+		for i, node := range nodes {
+			names[i] = node.Name
+		}
+		return nil, fmt.Errorf("mysshw:: '%s' matches several nodes: %s", query, strings.Join(names, ", "))
+	}
+
+	options := make([]huh.Option[int], len(nodes))
+	for i, node := range nodes {
+		label := node.Name
+		if node.Alias != "" {
+			label += "(" + node.Alias + ")"
+		}
+		label += " " + node.SetUser() + "@" + node.Host
+		if group := config.CFG.GroupOf(node); group != nil {
+			label += "  [" + config.CFG.GroupPath(group) + "]"
+		}
+		options[i] = huh.NewOption(label, i)
+	}
+	var selected int
+	err := huh.NewSelect[int]().
+		Title(fmt.Sprintf(ConnectChooseStr, query)).
+		Options(options...).
+		Value(&selected).
+		Run()
+	if err != nil {
+		return nil, nil
+	}
+	return nodes[selected], nil
+}
+
+// completeNodes 补全节点名称与别名，说明中显示所在的组与 user@host
+func completeNodes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	// 补全时只读取配置，不校验也不生成默认配置文件
+	if cfgPath, _ := cmd.Flags().GetString("cfg"); cfgPath != "" {
+		config.CFG_PATH = cfgPath
+	}
+	if err := config.LoadConfig(); err != nil || config.CFG == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	// 未校验的配置没有保存组路径，一次算出所有组的路径
+	paths, err := config.CFG.GroupPaths()
+	var completions []string
+	for i, group := range config.CFG.Nodes {
+		path := config.JoinGroupPath(group.Groups)
+		if err == nil {
+			path = paths[i]
+		}
+		for _, node := range group.SSHNodes {
+			desc := fmt.Sprintf("%s: %s@%s", path, node.SetUser(), node.Host)
+			for _, name := range []string{node.Name, node.Alias} {
+				if name != "" && strings.HasPrefix(name, toComplete) && !strings.ContainsAny(name, " \t") {
+					completions = append(completions, name+"\t"+desc)
+				}
+			}
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"mysshw/config"
+	"mysshw/ssh"
+
+	"github.com/spf13/cobra"
+)
+
+// controlCmd 管理连接复用后台进程
+var controlCmd = &cobra.Command{
+	Use:   "control",
+	Short: "Manage shared connections (ControlMaster-style multiplexing)",
+	Long: `Manage the background process that keeps authenticated connections to nodes.
+
+When "enable = true" is set in the [control] table, the first connection to a
+node starts a background mysshw process listening on the control socket
+(default ~/.mysshw/control.sock). It keeps the authenticated connection open
+and later shells, exec commands and forwards to the same node reuse it instead
+of dialing and authenticating again. Connections unused for idle_timeout
+(default 10m) are closed, and the process exits when none are left.
+
+Nodes that need a typed password or an unknown host key confirmation, and nodes
+with remote_forwards or forward_agent, are always connected directly.`,
+	Example: `  mysshw control ls
+  mysshw control stop prod-db
+  mysshw control stop`,
+}
+
+// controlLsCmd 列出复用连接
+var controlLsCmd = &cobra.Command{
+	Use:          "ls",
+	Short:        "List shared connections",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		infos, err := ssh.ControlList()
+		if err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if infos == nil {
+				infos = []ssh.ControlMasterInfo{}
+			}
+			return enc.Encode(infos)
+		}
+		if len(infos) == 0 {
+			fmt.Println(ControlNoConnectionsStr)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NODE\tADDRESS\tCLIENTS\tCONNECTED\tIDLE")
+		for _, info := range infos {
+			idle := "-"
+			if info.Clients == 0 {
+				idle = time.Since(info.LastUsed).Round(time.Second).String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", info.Node, info.Addr, info.Clients,
+				info.Connected.Format("2006-01-02 15:04:05"), idle)
+		}
+		return w.Flush()
+	},
+}
+
+// controlStopCmd 关闭复用连接或停止后台进程
+var controlStopCmd = &cobra.Command{
+	Use:          "stop [node]",
+	Short:        "Close the shared connection to a node, or all of them and stop the background process",
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		var name string
+		if len(args) == 1 {
+			// 后台进程按节点名称保存连接，允许使用别名
+			node, err := findNode(args[0])
+			if err != nil {
+				return err
+			}
+			name = node.Name
+		}
+
+		running, found, err := ssh.ControlStop(name)
+		switch {
+		case err != nil:
+			return fmt.Errorf("mysshw:: %v", err)
+		case !running:
+			fmt.Println(ControlNotRunningStr)
+		case name == "":
+			fmt.Println(ControlStoppedStr)
+		case !found:
+			return fmt.Errorf("mysshw:: no shared connection to '%s'", name)
+		default:
+			fmt.Printf(ControlClosedStr, name)
+		}
+		return nil
+	},
+}
+
+// controlDaemonCmd 连接复用后台进程，由 mysshw 自动启动
+var controlDaemonCmd = &cobra.Command{
+	Use:          "daemon",
+	Short:        "Run the connection sharing process in the foreground",
+	Hidden:       true,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		server, err := ssh.NewControlServer(config.CFG.ControlSocket(), config.CFG.ControlIdleTimeout())
+		if err != nil {
+			return err
+		}
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sig
+			server.Shutdown()
+		}()
+		return server.Serve()
+	},
+}
+
+func init() {
+	controlLsCmd.Flags().Bool("json", false, "Print the shared connections as JSON")
+	controlCmd.AddCommand(controlLsCmd)
+	controlCmd.AddCommand(controlStopCmd)
+	controlCmd.AddCommand(controlDaemonCmd)
+}
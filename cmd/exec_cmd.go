@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"mysshw/ssh"
+
+	"github.com/spf13/cobra"
+)
+
+// execExitCodeError 连接或认证失败时的退出码，与 OpenSSH 一致
+const execExitCodeError = 255
+
+// execCmd 在SSH节点或节点组上执行命令
+var execCmd = &cobra.Command{
+	Use:   "exec [<node> | --group <group> | --tags <selector>] -- <command> [args...]",
+	Short: "Run a command on an SSH node or a whole group",
+	Long: `Run a command on an SSH node without opening an interactive shell.
+
+The node is looked up by name or alias and uses its configured authentication.
+The remote stdout and stderr are streamed as they arrive, and mysshw exits with
+the remote exit status (255 if the connection or authentication fails).
+
+With --group the command runs on every node of the group concurrently, at most
+--parallel at a time. --tags selects the nodes by tag instead, e.g.
+"prod && !mysql" (operators: &&, ||, ! and parentheses), or narrows the group
+down when both are given. Output lines are prefixed with the node name, or with
+--collect shown per node together with its exit code once all nodes finished.
+A summary of successes, failures and timeouts is printed at the end, and mysshw
+exits non-zero if any node did not succeed. Group runs never prompt: nodes that
+need a password must have it configured, and unknown host keys are rejected.`,
+	Example: `  mysshw exec prod-db -- uptime
+  mysshw exec web01 -- systemctl status nginx
+
+  # Run on every node of a group, 10 at a time, 30 seconds per node
+  mysshw exec --group Groups01 --parallel 10 --timeout 30s -- uptime
+  mysshw exec -g Groups01 --collect -- df -h /
+
+  # Run on every node tagged prod but not mysql, in any group
+  mysshw exec --tags 'prod && !mysql' -- uptime`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		group, _ := cmd.Flags().GetString("group")
+		tags, _ := cmd.Flags().GetString("tags")
+		nodeArgs := cmd.ArgsLenAtDash()
+		if group != "" || tags != "" {
+			if nodeArgs != 0 || len(args) < 1 {
+				return fmt.Errorf("mysshw:: usage: mysshw exec [--group <group>] [--tags <selector>] -- <command> [args...]")
+			}
+			return nil
+		}
+		if nodeArgs != 1 || len(args) < 2 {
+			return fmt.Errorf("mysshw:: usage: mysshw exec <node> -- <command> [args...]")
+		}
+		return nil
+	},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		group, _ := cmd.Flags().GetString("group")
+		tags, _ := cmd.Flags().GetString("tags")
+		if group != "" || tags != "" {
+			opts := execGroupOptions{command: strings.Join(args, " ")}
+			opts.parallel, _ = cmd.Flags().GetInt("parallel")
+			opts.timeout, _ = cmd.Flags().GetDuration("timeout")
+			opts.collect, _ = cmd.Flags().GetBool("collect")
+			nodes := allNodes()
+			if group != "" {
+				var err error
+				if nodes, err = findGroupNodes(group); err != nil {
+					return err
+				}
+			}
+			nodes, err := selectNodes(cmd, nodes)
+			if err != nil {
+				return err
+			}
+			if !runExecGroup(ctx, nodes, opts) {
+				os.Exit(1)
+			}
+			return nil
+		}
+
+		node, err := findNode(args[0])
+		if err != nil {
+			return err
+		}
+		command := strings.Join(args[1:], " ")
+
+		code, err := ssh.NewClient(node).Exec(ctx, command, os.Stdout, os.Stderr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "mysshw::", err)
+			os.Exit(execExitCodeError)
+		}
+		os.Exit(code)
+		return nil
+	},
+}
+
+func init() {
+	execCmd.Flags().StringP("group", "g", "", "Run the command on every node of this group and its subgroups (name or path, e.g. cn-east/prod)")
+	execCmd.Flags().String("tags", "", "Run the command on every node whose tags match this selector, e.g. 'prod && !mysql'")
+	execCmd.Flags().IntP("parallel", "p", 5, "Maximum number of nodes to run on at the same time (with --group or --tags)")
+	execCmd.Flags().DurationP("timeout", "t", 0, "Time limit per node, e.g. 30s or 5m; 0 means no limit (with --group or --tags)")
+	execCmd.Flags().Bool("collect", false, "Collect each node's output and show it per node instead of prefixing lines (with --group or --tags)")
+}
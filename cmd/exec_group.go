@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"mysshw/config"
+	"mysshw/ssh"
+
+	"github.com/spf13/cobra"
+)
+
+// execGroupOptions 节点组批量执行参数
+type execGroupOptions struct {
+	command  string
+	parallel int
+	timeout  time.Duration
+	collect  bool // 收集输出后按节点显示，否则逐行加节点前缀输出
+}
+
+// execResult 单个节点的执行结果
+type execResult struct {
+	node     *config.SSHNode
+	code     int
+	err      error
+	timedOut bool
+	output   bytes.Buffer // collect 模式下的输出
+}
+
+// findGroupNodes 按组路径或组名查找节点组，返回其中及其下级分组中的全部节点
+func findGroupNodes(name string) ([]*config.SSHNode, error) {
+	path := name
+	if group := config.CFG.FindGroup(name); group != nil {
+		path = config.CFG.GroupPath(group)
+	}
+	groups := config.CFG.GroupsUnder(path)
+	if len(groups) == 0 || config.JoinGroupPath(path) == "" {
+		return nil, fmt.Errorf("mysshw:: SSH node group '%s' not found in config", name)
+	}
+	var nodes []*config.SSHNode
+	for _, group := range groups {
+		nodes = append(nodes, group.SSHNodes...)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("mysshw:: SSH node group '%s' has no nodes", name)
+	}
+	return nodes, nil
+}
+
+// allNodes 返回配置中的全部节点，按配置顺序排列
+func allNodes() []*config.SSHNode {
+	var nodes []*config.SSHNode
+	for _, group := range config.CFG.Nodes {
+		nodes = append(nodes, group.SSHNodes...)
+	}
+	return nodes
+}
+
+// tagsSelector 解析 --tags 参数，没有设置时返回nil
+func tagsSelector(cmd *cobra.Command) (*config.Selector, error) {
+	expr, _ := cmd.Flags().GetString("tags")
+	sel, err := config.ParseSelector(expr)
+	if err != nil {
+		return nil, fmt.Errorf("mysshw:: %v", err)
+	}
+	return sel, nil
+}
+
+// selectNodes 返回标签符合 --tags 的节点，没有设置 --tags 时原样返回；没有节点符合时返回错误
+func selectNodes(cmd *cobra.Command, nodes []*config.SSHNode) ([]*config.SSHNode, error) {
+	sel, err := tagsSelector(cmd)
+	if err != nil || sel == nil {
+		return nodes, err
+	}
+	var selected []*config.SSHNode
+	for _, node := range nodes {
+		if sel.Match(node) {
+			selected = append(selected, node)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("mysshw:: no nodes match the tags '%s'", sel)
+	}
+	return selected, nil
+}
+
+// runExecGroup 在多个节点上并发执行命令并打印汇总，全部成功时返回true
+func runExecGroup(ctx context.Context, nodes []*config.SSHNode, opts execGroupOptions) bool {
+	if opts.parallel < 1 {
+		opts.parallel = 1
+	}
+	width := 0
+	for _, node := range nodes {
+		width = max(width, len(node.Name))
+	}
+
+	var mu sync.Mutex // 保证不同节点的输出行不会交错
+	results := make([]*execResult, len(nodes))
+	sem := make(chan struct{}, opts.parallel)
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		results[i] = &execResult{node: node}
+		wg.Add(1)
+		go func(r *execResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var stdout, stderr io.Writer
+			if opts.collect {
+				// stdout 与 stderr 在各自的 goroutine 中写入同一个缓冲
+				out := &lockedWriter{mu: &sync.Mutex{}, w: &r.output}
+				stdout, stderr = out, out
+			} else {
+				prefix := fmt.Sprintf("%-*s | ", width, r.node.Name)
+				out := &prefixWriter{mu: &mu, w: os.Stdout, prefix: prefix}
+				errOut := &prefixWriter{mu: &mu, w: os.Stderr, prefix: prefix}
+				defer out.Flush()
+				defer errOut.Flush()
+				stdout, stderr = out, errOut
+			}
+			execOnNode(ctx, r, opts, stdout, stderr)
+		}(results[i])
+	}
+	wg.Wait()
+
+	if opts.collect {
+		for _, r := range results {
+			fmt.Printf(ExecCollectHeaderStr, r.node.Name, r.node.SetUser(), r.node.Host, r.status())
+			os.Stdout.Write(r.output.Bytes())
+			if n := r.output.Len(); n > 0 && r.output.Bytes()[n-1] != '\n' {
+				fmt.Println()
+			}
+		}
+	}
+	return printExecSummary(results)
+}
+
+// execOnNode 在单个节点上执行命令，超时由 opts.timeout 控制
+func execOnNode(ctx context.Context, r *execResult, opts execGroupOptions, stdout, stderr io.Writer) {
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	r.code, r.err = ssh.NewBatchClient(r.node).Exec(ctx, opts.command, stdout, stderr)
+	if r.err == nil {
+		return
+	}
+	var netErr net.Error
+	if errors.Is(r.err, context.DeadlineExceeded) || (errors.As(r.err, &netErr) && netErr.Timeout()) {
+		r.timedOut = true
+	}
+	if !opts.collect {
+		fmt.Fprintf(stderr, "mysshw:: %s\n", r.status())
+	}
+}
+
+// status 返回结果的简短描述
+func (r *execResult) status() string {
+	switch {
+	case r.timedOut:
+		return "timed out"
+	case r.err != nil:
+		return "error: " + r.err.Error()
+	default:
+		return fmt.Sprintf("exit %d", r.code)
+	}
+}
+
+// printExecSummary 在 stderr 打印成功、失败和超时的节点汇总，全部成功时返回true
+func printExecSummary(results []*execResult) bool {
+	var ok int
+	var failed, timedOut []string
+	for _, r := range results {
+		switch {
+		case r.timedOut:
+			timedOut = append(timedOut, r.node.Name)
+		case r.err != nil || r.code != 0:
+			failed = append(failed, fmt.Sprintf("%s (%s)", r.node.Name, r.status()))
+		default:
+			ok++
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, ExecSummaryStr, len(results), ok, len(failed), len(timedOut))
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, ExecSummaryFailedStr, strings.Join(failed, "\n    "))
+	}
+	if len(timedOut) > 0 {
+		fmt.Fprintf(os.Stderr, ExecSummaryTimeoutStr, strings.Join(timedOut, ", "))
+	}
+	return len(failed) == 0 && len(timedOut) == 0
+}
+
+// prefixWriter 按行写入并在每行前加上节点前缀，多个节点共享同一把锁
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.writeLine(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush 输出缓冲中不以换行结尾的最后一行
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	io.WriteString(p.w, p.prefix)
+	p.w.Write(line)
+}
+
+// lockedWriter 持有 mu 期间写入 w，用于多个 goroutine 写入同一个缓冲
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(b)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"mysshw/config"
+
+	"github.com/spf13/cobra"
+)
+
+// exportCmd 将节点导出为其他工具可用的格式
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export nodes for use by other tools",
+}
+
+// exportSSHConfigCmd 将节点导出为 OpenSSH 配置
+var exportSSHConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Export all nodes as OpenSSH config Host blocks",
+	Long: `Render every node of the mysshw config as an OpenSSH "Host" block, so that
+plain ssh, scp, rsync or ansible can use the same inventory.
+
+The alias and the name of a node become the Host patterns, and host, user,
+port, keypath, certfile, jump and forward_agent are converted to HostName,
+User, Port, IdentityFile, CertificateFile, ProxyJump and ForwardAgent.
+Passwords cannot be exported; ssh asks for them when needed. With --tags only
+the nodes whose tags match the selector are exported; their jump hosts are
+referenced by name and must be exported as well to be usable.
+
+The result is printed to stdout, or written to a file with --output. To keep
+such a file up to date automatically, set it in the config:
+
+  [export]
+  ssh_config = "~/.ssh/config.d/mysshw"
+
+mysshw then regenerates it whenever the config changes. Add
+"Include config.d/mysshw" near the top of ~/.ssh/config to use it.`,
+	Example: `  mysshw export ssh-config
+  mysshw export ssh-config -o ~/.ssh/config.d/mysshw
+  mysshw export ssh-config --tags 'prod && !mysql'`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		sel, err := tagsSelector(cmd)
+		if err != nil {
+			return err
+		}
+		content := config.CFG.OpenSSHConfig(fmt.Sprintf(config.OpenSSHExportHeader, config.CFG_PATH), sel)
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			fmt.Print(content)
+			return nil
+		}
+
+		path, err := config.ExpandHomeDir(output)
+		if err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		fmt.Printf(ExportWrittenStr, path, path)
+		return nil
+	},
+}
+
+func init() {
+	exportSSHConfigCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+	exportSSHConfigCmd.Flags().String("tags", "", "Only export the nodes whose tags match this selector, e.g. 'prod && !mysql'")
+	exportCmd.AddCommand(exportSSHConfigCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"mysshw/config"
+	"mysshw/ssh"
+
+	"github.com/spf13/cobra"
+)
+
+// forwardCmd 通过SSH节点进行本地(-L)与远程(-R)端口转发
+var forwardCmd = &cobra.Command{
+	Use:   "forward <node> [-L spec] [-R spec]",
+	Short: "Forward ports through an SSH node (local -L and remote -R)",
+	Long: `Forward ports through an SSH node.
+
+  -L [bind_address:]port:host:hostport  listen locally, connect to host:hostport from the node (like ssh -L)
+  -R [bind_address:]port:host:hostport  listen on the node, connect to host:hostport locally (like ssh -R)
+
+The node is looked up by name or alias. Forwards configured on the node with
+"forwards = [...]" and "remote_forwards = [...]" are started together with the
+ones given on the command line.
+By default no shell is opened (like ssh -N); use --shell to open one as well.`,
+	Example: `  # Tunnel a remote database to local port 5432
+  mysshw forward prod-db -L 5432:127.0.0.1:5432
+
+  # Several forwards plus an interactive shell
+  mysshw forward bastion -L 8080:admin.internal:80 -L 9090:grafana.internal:3000 --shell
+
+  # Expose a local dev server on port 8000 of the remote box
+  mysshw forward dev-box -R 8000:127.0.0.1:3000`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		node, err := findNode(args[0])
+		if err != nil {
+			return err
+		}
+
+		locals, _ := cmd.Flags().GetStringArray("local")
+		remotes, _ := cmd.Flags().GetStringArray("remote")
+		shell, _ := cmd.Flags().GetBool("shell")
+
+		// 在节点副本上追加命令行参数，不修改已加载的配置
+		n := node.Copy()
+		n.Forwards = append(append(config.StringList{}, node.Forwards...), locals...)
+		n.RemoteForwards = append(append(config.StringList{}, node.RemoteForwards...), remotes...)
+		if _, err := config.ParseForwards(n.Forwards); err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		if _, err := config.ParseForwards(n.RemoteForwards); err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+
+		client := ssh.NewClient(n)
+		if shell {
+			client.Login(nil)
+			return nil
+		}
+
+		// Ctrl+C 退出转发
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Println(ForwardCtrlCHintStr)
+		if err := client.Forward(ctx); err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		fmt.Println(GlobalExitingStr)
+		return nil
+	},
+}
+
+func init() {
+	forwardCmd.Flags().StringArrayP("local", "L", nil, "Local forward [bind_address:]port:host:hostport (repeatable)")
+	forwardCmd.Flags().StringArrayP("remote", "R", nil, "Remote forward [bind_address:]port:host:hostport (repeatable)")
+	forwardCmd.Flags().BoolP("shell", "s", false, "Open an interactive shell alongside the forwards")
+}
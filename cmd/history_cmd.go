@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"mysshw/config"
+	"mysshw/state"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultHistoryLimit history 命令默认显示的记录数
+const defaultHistoryLimit = 20
+
+// historyCmd 显示连接历史
+var historyCmd = &cobra.Command{
+	Use:   "history [node]",
+	Short: "Show recently connected nodes",
+	Long: `Show the connection history, most recent first.
+
+Every session started from the node menu or by "mysshw <node>" is recorded in
+$XDG_STATE_HOME/mysshw/state.json (default ~/.local/state/mysshw/state.json)
+together with the nodes pinned in the menu with ctrl+s. The most recently used
+nodes are listed under "Recent" at the top of the menu.`,
+	Example: `  mysshw history
+  mysshw history -n 50 prod-db
+  mysshw history --json`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		st, err := state.Load()
+		if err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		entries := []state.Entry{}
+		for i := len(st.History) - 1; i >= 0 && (limit <= 0 || len(entries) < limit); i-- {
+			if len(args) == 0 || st.History[i].Node == args[0] {
+				entries = append(entries, st.History[i])
+			}
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+		if len(entries) == 0 {
+			fmt.Println(HistoryEmptyStr)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tNODE\tGROUP\tADDRESS\tDURATION")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s@%s\t%s\n", e.Time.Local().Format("2006-01-02 15:04:05"),
+				e.Node, orDash(e.Group), e.User, net.JoinHostPort(e.Host, strconv.Itoa(e.Port)), e.Duration.Round(time.Second))
+		}
+		return w.Flush()
+	},
+}
+
+// recordHistory 在连接历史中记录一次会话，记录失败只输出警告
+func recordHistory(node *config.SSHNode, start time.Time) {
+	entry := state.Entry{
+		Node:     node.Name,
+		User:     node.SetUser(),
+		Host:     node.Host,
+		Port:     node.SetPort(),
+		Time:     start,
+		Duration: time.Since(start),
+	}
+	entry.Group = config.CFG.GroupPath(config.CFG.GroupOf(node))
+	if err := state.Record(entry); err != nil {
+		fmt.Fprintf(os.Stderr, HistoryRecordWarnStr, err)
+	}
+}
+
+func init() {
+	historyCmd.Flags().IntP("limit", "n", defaultHistoryLimit, "Number of entries to show, 0 shows all")
+	historyCmd.Flags().Bool("json", false, "Print the history as JSON")
+}
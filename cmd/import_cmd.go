@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"mysshw/config"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+// importCmd 从其他配置导入节点
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import nodes from other SSH configuration files",
+}
+
+// importSSHConfigCmd 从 OpenSSH 配置文件导入节点
+var importSSHConfigCmd = &cobra.Command{
+	Use:   "ssh-config [path]",
+	Short: "Import hosts from an OpenSSH config file (default ~/.ssh/config)",
+	Long: `Import the hosts defined in an OpenSSH client config file as mysshw nodes.
+
+Every concrete "Host" alias becomes a node named after the alias, with
+HostName, User, Port, IdentityFile and ProxyJump converted to host, user, port,
+keypath and jump. Settings from wildcard blocks such as "Host *" are applied
+as defaults, the first value of an option wins as in OpenSSH, and "Include"
+files are followed. "Match" blocks are not supported and are skipped. A host
+without any User is imported with the current user name, as ssh would log in.
+
+The converted nodes are shown first and appended to the mysshw config file as a
+new group after confirmation. Nodes whose name already exists in the config are
+skipped, so the import can be repeated after adding hosts.`,
+	Example: `  mysshw import ssh-config
+  mysshw import ssh-config ~/.ssh/config.d/work --group work
+  mysshw import ssh-config --dry-run`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		path := filepath.Join("~", ".ssh", "config")
+		if len(args) > 0 {
+			path = args[0]
+		}
+		path, err := config.ExpandHomeDir(path)
+		if err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+
+		hosts, err := config.ParseOpenSSHConfig(path)
+		if err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		group, _ := cmd.Flags().GetString("group")
+		nodes := convertOpenSSHHosts(hosts)
+		if len(nodes) == 0 {
+			fmt.Printf(ImportNothingStr, path)
+			return nil
+		}
+		if config.CFG.FindGroup(group) != nil {
+			return fmt.Errorf("mysshw:: group '%s' already exists in the config, choose another one with --group", group)
+		}
+
+		content := renderNodesTOML(fmt.Sprintf("imported from %s", path), group, nodes)
+		fmt.Print(content)
+		if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+			return nil
+		}
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes {
+			confirmed := false
+			err := huh.NewConfirm().
+				Title(fmt.Sprintf(ImportConfirmStr, len(nodes), group, config.CFG_PATH)).
+				Value(&confirmed).
+				Run()
+			if err != nil || !confirmed {
+				fmt.Println(ImportCancelledStr)
+				return nil
+			}
+		}
+
+		if err := mergeIntoConfigFile(config.CFG_PATH, content); err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		fmt.Printf(ImportDoneStr, len(nodes), group, config.CFG_PATH)
+		return nil
+	},
+}
+
+func init() {
+	importSSHConfigCmd.Flags().StringP("group", "g", "ssh-config", "Name of the group the imported nodes are added to")
+	importSSHConfigCmd.Flags().BoolP("yes", "y", false, "Merge without asking for confirmation")
+	importSSHConfigCmd.Flags().Bool("dry-run", false, "Only show the converted nodes")
+	importCmd.AddCommand(importSSHConfigCmd)
+}
+
+// convertOpenSSHHosts 将 OpenSSH 主机转换为节点，跳过配置中已存在的节点
+// ProxyJump 只能引用导入的主机或配置中已有的节点，私钥文件不存在时不写入 keypath
+func convertOpenSSHHosts(hosts []config.OpenSSHHost) []*config.SSHNode {
+	imported := map[string]bool{}
+	for _, h := range hosts {
+		imported[h.Alias] = true
+	}
+	knownJump := func(ref string) bool {
+		return imported[ref] || config.CFG.FindNode(ref) != nil
+	}
+
+	var nodes []*config.SSHNode
+	for _, h := range hosts {
+		if config.CFG.FindNode(h.Alias) != nil {
+			fmt.Fprintf(os.Stderr, ImportSkipExistingStr, h.Alias)
+			continue
+		}
+		node, skipped := h.SSHNode(knownJump)
+		for _, jump := range skipped {
+			fmt.Fprintf(os.Stderr, ImportSkipJumpStr, h.Alias, jump)
+		}
+		var keys config.StringList
+		for _, key := range node.KeyPath {
+			expanded, err := config.ExpandHomeDir(key)
+			if err == nil {
+				_, err = os.Stat(expanded)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, ImportSkipKeyStr, h.Alias, key)
+				continue
+			}
+			keys = append(keys, key)
+		}
+		node.KeyPath = keys
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// renderNodesTOML 将节点渲染为一个 [[nodes]] 组
+func renderNodesTOML(comment, group string, nodes []*config.SSHNode) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", comment)
+	fmt.Fprintf(&b, "[[nodes]]\ngroups = %s\n\n", strconv.Quote(group))
+	for _, node := range nodes {
+		b.WriteString("[[nodes.ssh]]\n")
+		fmt.Fprintf(&b, "name = %s\n", strconv.Quote(node.Name))
+		fmt.Fprintf(&b, "host = %s\n", strconv.Quote(node.Host))
+		if node.User != "" {
+			fmt.Fprintf(&b, "user = %s\n", strconv.Quote(node.User))
+		}
+		if node.Port > 0 {
+			fmt.Fprintf(&b, "port = %d\n", node.Port)
+		}
+		if len(node.KeyPath) > 0 {
+			fmt.Fprintf(&b, "keypath = %s\n", tomlStringArray(node.KeyPath))
+		}
+		if len(node.Jump) > 0 {
+			fmt.Fprintf(&b, "jump = %s\n", tomlStringArray(node.Jump))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// tomlStringArray 将字符串列表渲染为 TOML 数组
+func tomlStringArray(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = strconv.Quote(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// mergeIntoConfigFile 将内容追加到配置文件，合并后的配置无效时恢复原文件
+// 写入与恢复都保留原文件的权限，配置中可能有密码
+func mergeIntoConfigFile(cfgPath, content string) error {
+	info, err := os.Stat(cfgPath)
+	if err != nil {
+		return err
+	}
+	original, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return err
+	}
+	merged := string(original)
+	if !strings.HasSuffix(merged, "\n") {
+		merged += "\n"
+	}
+	merged += "\n" + content
+	if err := os.WriteFile(cfgPath, []byte(merged), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := config.LoadViperConfig(cfgPath); err != nil {
+		if restoreErr := os.WriteFile(cfgPath, original, info.Mode().Perm()); restoreErr != nil {
+			return fmt.Errorf("%v (restoring the config file also failed: %v)", err, restoreErr)
+		}
+		return fmt.Errorf("the merged config is invalid, nothing was changed: %v", err)
+	}
+	return nil
+}
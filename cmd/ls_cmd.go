@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"mysshw/config"
+
+	"github.com/spf13/cobra"
+)
+
+// lsEntry ls 命令输出的一个节点
+type lsEntry struct {
+	Group string   `json:"group"`
+	Name  string   `json:"name"`
+	Alias string   `json:"alias,omitempty"`
+	User  string   `json:"user"`
+	Host  string   `json:"host"`
+	Port  int      `json:"port"`
+	Tags  []string `json:"tags"`
+	Notes string   `json:"notes,omitempty"`
+}
+
+// lsCmd 列出节点
+var lsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List nodes, optionally selected by tags",
+	Long: `List the nodes of the config with their group, address and tags.
+
+--tags selects the nodes whose tags match a selector: tag names combined with
+&& (and), || (or), ! (not) and parentheses, e.g. "prod && !mysql" or
+"(web || api) && !staging". The same selector is accepted by the node menu,
+exec, ping and export ssh-config.`,
+	Example: `  mysshw ls
+  mysshw ls --tags 'prod && !mysql'
+  mysshw ls --group cn-east --json`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		nodes := allNodes()
+		if group, _ := cmd.Flags().GetString("group"); group != "" {
+			var err error
+			if nodes, err = findGroupNodes(group); err != nil {
+				return err
+			}
+		}
+		nodes, err := selectNodes(cmd, nodes)
+		if err != nil {
+			return err
+		}
+
+		entries := make([]lsEntry, len(nodes))
+		for i, node := range nodes {
+			entries[i] = lsEntry{
+				Group: config.CFG.GroupPath(config.CFG.GroupOf(node)),
+				Name:  node.Name,
+				Alias: node.Alias,
+				User:  node.SetUser(),
+				Host:  node.Host,
+				Port:  node.SetPort(),
+				Tags:  append([]string{}, node.Tags...),
+				Notes: node.Notes,
+			}
+		}
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+		if len(entries) == 0 {
+			fmt.Println(LsNoNodesStr)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "GROUP\tNODE\tALIAS\tADDRESS\tTAGS")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s@%s\t%s\n", e.Group, e.Name, orDash(e.Alias), e.User,
+				net.JoinHostPort(e.Host, strconv.Itoa(e.Port)), orDash(strings.Join(e.Tags, ",")))
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	lsCmd.Flags().String("tags", "", "Only list the nodes whose tags match this selector, e.g. 'prod && !mysql'")
+	lsCmd.Flags().StringP("group", "g", "", "Only list the nodes of this group and its subgroups (name or path)")
+	lsCmd.Flags().Bool("json", false, "Print the nodes as JSON")
+}
@@ -10,4 +10,31 @@ const (
 	RunSSHCtrlCResultStr                   = "\nReceived termination signal, mysshw:: Exiting..."
 	RunSSHCtrlDResultStr                   = "\nReceived Ctrl+D, mysshw:: Exiting..."
 	RunSSHInputQResultStr                  = "\nReceived input %s, mysshw:: Exiting...\n"
+	ForwardCtrlCHintStr                    = `Press "Ctrl+C" to stop forwarding.`
+	SocksCtrlCHintStr                      = `Press "Ctrl+C" to stop the SOCKS5 proxy.`
+	ExecCollectHeaderStr                   = "==> %s (%s@%s): %s <==\n"
+	ExecSummaryStr                         = "\nmysshw:: %d nodes: %d succeeded, %d failed, %d timed out\n"
+	ExecSummaryFailedStr                   = "  failed:\n    %s\n"
+	ExecSummaryTimeoutStr                  = "  timed out: %s\n"
+	ControlNoConnectionsStr                = "mysshw:: no shared connections"
+	ControlNotRunningStr                   = "mysshw:: connection sharing is not running"
+	ControlStoppedStr                      = "mysshw:: closed all shared connections"
+	ControlClosedStr                       = "mysshw:: closed the shared connection to '%s'\n"
+	ImportNothingStr                       = "mysshw:: no new hosts to import from %s\n"
+	ImportSkipExistingStr                  = "mysshw:: skipping '%s': a node with this name already exists\n"
+	ImportSkipJumpStr                      = "mysshw:: '%s': ProxyJump '%s' is not a known host, jump not imported\n"
+	ImportSkipKeyStr                       = "mysshw:: '%s': IdentityFile '%s' not found, keypath not imported\n"
+	ImportConfirmStr                       = "Add %d nodes as group '%s' to %s?"
+	ImportCancelledStr                     = "mysshw:: import cancelled, the config file was not changed"
+	ImportDoneStr                          = "mysshw:: added %d nodes as group '%s' to %s\n"
+	ExportWrittenStr                       = "mysshw:: exported to %s, add \"Include %s\" to ~/.ssh/config to use it\n"
+	ConnectAdHocStr                        = "mysshw:: '%s' is not in the config, connecting to %s@%s:%d\n"
+	ConnectFuzzyStr                        = "mysshw:: '%s' matches node '%s'\n"
+	ConnectChooseStr                       = "Several nodes match '%s', select one:"
+	ConnectCancelledStr                    = "Operation cancelled"
+	PingFailedStr                          = "mysshw:: %d of %d nodes failed the check\n"
+	HistoryEmptyStr                        = "mysshw:: no connection history"
+	HistoryRecordWarnStr                   = "mysshw:: Warning: connection history not saved: %v\n"
+	RunSSHNoTaggedNodesStr                 = "mysshw:: no nodes match the tags '%s'\n"
+	LsNoNodesStr                           = "mysshw:: no nodes"
 )
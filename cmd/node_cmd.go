@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+
+	"mysshw/config"
+
+	"github.com/spf13/cobra"
+)
+
+// loadCmdConfig 处理 --cfg 参数并加载配置文件
+func loadCmdConfig(cmd *cobra.Command) error {
+	cfgPath, _ := cmd.Flags().GetString("cfg")
+	if cfgPath != "" {
+		config.CFG_PATH = cfgPath
+	}
+	return loadConfig()
+}
+
+// findNode 按名称或别名查找配置中的SSH节点
+func findNode(key string) (*config.SSHNode, error) {
+	node := config.CFG.FindNode(key)
+	if node == nil {
+		return nil, fmt.Errorf("mysshw:: SSH node '%s' not found in config", key)
+	}
+	return node, nil
+}
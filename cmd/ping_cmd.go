@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"mysshw/config"
+	"mysshw/ssh"
+
+	"github.com/spf13/cobra"
+)
+
+// pingCmd 检查节点的连通性
+var pingCmd = &cobra.Command{
+	Use:   "ping [node...]",
+	Short: "Check that nodes are reachable",
+	Long: `Check the reachability of every node in the config, or of the given nodes
+or group, and report the latency, the server version and the auth outcome.
+--tags narrows the nodes down to those whose tags match a selector such as
+"prod && !mysql" (operators: &&, ||, ! and parentheses).
+
+By default only the TCP port is probed. With --handshake the SSH handshake is
+completed and the host key verified against known_hosts; with --auth the node
+also logs in with its configured key, agent or password. Probes never prompt:
+unknown host keys and encrypted keys without a passphrase count as failures.
+Nodes behind jump hosts are probed through their jumps.
+
+Probes run concurrently, each limited by --timeout. The exit status is 1 when
+any node fails, so the command can run from cron.`,
+	Example: `  mysshw ping
+  mysshw ping --group Groups01 --auth
+  mysshw ping prod-db web01 --handshake
+  mysshw ping --tags 'prod && !mysql'
+  mysshw ping --parallel 20 --timeout 3s --json`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		targets, err := pingTargets(cmd, args)
+		if err != nil {
+			return err
+		}
+		level := ssh.ProbeTCP
+		if handshake, _ := cmd.Flags().GetBool("handshake"); handshake {
+			level = ssh.ProbeHandshake
+		}
+		if auth, _ := cmd.Flags().GetBool("auth"); auth {
+			level = ssh.ProbeAuth
+		}
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		results := make([]ssh.ProbeResult, len(targets))
+		sem := make(chan struct{}, max(parallel, 1))
+		var wg sync.WaitGroup
+		for i, t := range targets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				results[i] = ssh.Probe(ctx, t.node, level, timeout)
+				results[i].Group = t.group
+			}()
+		}
+		wg.Wait()
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				return err
+			}
+		} else if err := printPingTable(results, level); err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, r := range results {
+			if !r.OK(level) {
+				failed++
+			}
+		}
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, PingFailedStr, failed, len(results))
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	pingCmd.Flags().StringP("group", "g", "", "Only check the nodes of this group and its subgroups (name or path)")
+	pingCmd.Flags().String("tags", "", "Only check the nodes whose tags match this selector, e.g. 'prod && !mysql'")
+	pingCmd.Flags().IntP("parallel", "p", 10, "Maximum number of nodes to check at the same time")
+	pingCmd.Flags().DurationP("timeout", "t", defaultPingTimeout, "Time limit per node, e.g. 3s")
+	pingCmd.Flags().Bool("handshake", false, "Complete the SSH handshake and verify the host key")
+	pingCmd.Flags().Bool("auth", false, "Also log in with the node's credentials (implies --handshake)")
+	pingCmd.Flags().Bool("json", false, "Print the results as JSON")
+}
+
+// defaultPingTimeout 每个节点默认的检查时间限制
+const defaultPingTimeout = 5 * time.Second
+
+// pingTarget 待检查的节点及其所在的组
+type pingTarget struct {
+	group string
+	node  *config.SSHNode
+}
+
+// pingTargets 返回参数指定的节点，或 --group 指定组的节点，都没有指定时返回全部节点；再按 --tags 过滤
+func pingTargets(cmd *cobra.Command, args []string) ([]pingTarget, error) {
+	group, _ := cmd.Flags().GetString("group")
+	if group != "" && len(args) > 0 {
+		return nil, fmt.Errorf("mysshw:: usage: mysshw ping [node...] | --group <group>")
+	}
+
+	var nodes []*config.SSHNode
+	switch {
+	case len(args) > 0:
+		for _, name := range args {
+			node, err := findNode(name)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		}
+	case group != "":
+		var err error
+		if nodes, err = findGroupNodes(group); err != nil {
+			return nil, err
+		}
+	default:
+		nodes = allNodes()
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("mysshw:: no nodes in config")
+		}
+	}
+	nodes, err := selectNodes(cmd, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]pingTarget, len(nodes))
+	for i, node := range nodes {
+		targets[i] = pingTarget{group: config.CFG.GroupPath(config.CFG.GroupOf(node)), node: node}
+	}
+	return targets, nil
+}
+
+// printPingTable 以表格输出检查结果，只显示 level 检查到的列
+func printPingTable(results []ssh.ProbeResult, level ssh.ProbeLevel) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "GROUP\tNODE\tADDRESS\tSTATUS\tLATENCY"
+	if level >= ssh.ProbeHandshake {
+		header += "\tVERSION"
+	}
+	if level >= ssh.ProbeAuth {
+		header += "\tAUTH"
+	}
+	fmt.Fprintln(w, header+"\tERROR")
+	for _, r := range results {
+		status, latency := "ok", "-"
+		if !r.OK(level) {
+			status = "FAIL"
+		}
+		if r.TimedOut {
+			status = "TIMEOUT"
+		}
+		if r.Reachable {
+			latency = fmt.Sprintf("%.1fms", r.LatencyMS)
+		}
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", r.Group, r.Node, r.Address, status, latency)
+		if level >= ssh.ProbeHandshake {
+			line += "\t" + orDash(r.ServerVersion)
+		}
+		if level >= ssh.ProbeAuth {
+			line += "\t" + orDash(r.Auth)
+		}
+		fmt.Fprintln(w, line+"\t"+orDash(r.Error))
+	}
+	return w.Flush()
+}
+
+// orDash 空字符串显示为 -
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
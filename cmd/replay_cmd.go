@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"mysshw/ssh"
+
+	"github.com/spf13/cobra"
+)
+
+// replayCmd 回放录制的终端会话
+var replayCmd = &cobra.Command{
+	Use:   "replay <file>",
+	Short: "Play back a recorded session (asciicast v2 .cast file)",
+	Long: `Play back a terminal session recorded by mysshw in the terminal.
+
+Sessions are recorded in asciicast v2 format when "record = true" is set on a
+node or "enable = true" in the [record] table, and are saved under the
+[record] dir (default ~/.mysshw/recordings). The files can also be played with
+asciinema or uploaded to asciinema.org.`,
+	Example: `  mysshw replay ~/.mysshw/recordings/prod-db-20250101-120000.cast
+
+  # Play twice as fast and shorten pauses longer than 2 seconds
+  mysshw replay --speed 2 --idle-limit 2s session.cast`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		speed, _ := cmd.Flags().GetFloat64("speed")
+		idleLimit, _ := cmd.Flags().GetDuration("idle-limit")
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err := ssh.Replay(ctx, args[0], os.Stdout, speed, idleLimit)
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	},
+}
+
+func init() {
+	replayCmd.Flags().Float64P("speed", "s", 1, "Playback speed multiplier, e.g. 2 for twice as fast")
+	replayCmd.Flags().Duration("idle-limit", 0, "Limit pauses between outputs to this duration, e.g. 2s; 0 means no limit")
+}
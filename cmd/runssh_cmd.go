@@ -25,6 +25,11 @@ func RunSSH(ctx context.Context) {
 		fmt.Println("mysshw:: Load Config Error::", err)
 		os.Exit(1)
 	}
+	sel, _ := ctx.Value(tagsKey).(*config.Selector)
+	if len(config.CFG.Select(sel).Nodes) == 0 {
+		fmt.Printf(RunSSHNoTaggedNodesStr, sel)
+		os.Exit(1)
+	}
 
 	// 设置信号处理捕获Ctrl+C和SIGTERM
 	sigChan := make(chan os.Signal, 1)
@@ -43,7 +48,7 @@ func RunSSH(ctx context.Context) {
 		fmt.Print(GlobalScreenClearingStr)
 		// 检查操作系统类型
 		fmt.Println(fmtExitingDesc())
-		node := ssh.Choose(config.CFG)
+		node := ssh.Choose(config.CFG.Select(sel))
 		client := ssh.NewClient(node)
 		// 检查是否按下q键
 		select {
@@ -66,11 +71,16 @@ func RunSSH(ctx context.Context) {
 		}
 
 		// 传递会话结束回调函数，在SSH会话结束后返回主界面
-		client.Login(func() {
+		start := time.Now()
+		established := client.Login(func() {
 			fmt.Println(RunSSHClientLoginSessionEndCallbackStr)
 			// 清屏
 			fmt.Print(GlobalScreenClearingStr)
 		})
+		// 只记录成功打开的会话，连接或认证失败的不记录
+		if node != nil && established {
+			recordHistory(node, start)
+		}
 		// 会话结束后继续循环，重新显示菜单
 	}
 }
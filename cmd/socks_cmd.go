@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"mysshw/ssh"
+
+	"github.com/spf13/cobra"
+)
+
+// socksCmd 通过SSH节点运行本地 SOCKS5 代理(类似 ssh -D)
+var socksCmd = &cobra.Command{
+	Use:   "socks <node>",
+	Short: "Run a local SOCKS5 proxy through an SSH node (like ssh -D)",
+	Long: `Run a local SOCKS5 proxy through an SSH node (like ssh -D).
+
+Every CONNECT request is dialed from the node, so internal networks behind it
+become reachable from the browser or any SOCKS-aware tool. Domain names are
+resolved by the node by default (socks5h); use --local-dns to resolve them locally.`,
+	Example: `  # Browse the internal network through the bastion
+  mysshw socks bastion --listen 127.0.0.1:1080
+  curl --socks5-hostname 127.0.0.1:1080 http://intranet.local/`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadCmdConfig(cmd); err != nil {
+			return err
+		}
+		node, err := findNode(args[0])
+		if err != nil {
+			return err
+		}
+
+		listen, _ := cmd.Flags().GetString("listen")
+		localDNS, _ := cmd.Flags().GetBool("local-dns")
+
+		// Ctrl+C 退出代理
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		fmt.Println(SocksCtrlCHintStr)
+		if err := ssh.NewClient(node).Socks(ctx, listen, localDNS); err != nil {
+			return fmt.Errorf("mysshw:: %v", err)
+		}
+		fmt.Println(GlobalExitingStr)
+		return nil
+	},
+}
+
+func init() {
+	socksCmd.Flags().StringP("listen", "l", "127.0.0.1:1080", "Local address for the SOCKS5 proxy")
+	socksCmd.Flags().Bool("local-dns", false, "Resolve domain names locally instead of on the node")
+}
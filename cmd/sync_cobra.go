@@ -8,6 +8,7 @@ import (
 	"mysshw/config"
 	"mysshw/s3"
 	"mysshw/scp"
+	"mysshw/ssh"
 	"mysshw/webdav"
 	"os"
 	"path/filepath"
@@ -55,7 +56,7 @@ var syncCmd = &cobra.Command{
 			sshCfg := createSSHConfig(syncCfg)
 
 			// 创建 SCP 客户端并连接
-			client, err := createSCPclient(syncCfg.RemoteUri, sshCfg)
+			client, err := createSCPclient(syncCfg.RemoteUri, syncCfg.SCPConfig.Jump, sshCfg)
 			if err != nil {
 				fmt.Printf("Failed to create SCP client: %s\n", err)
 				os.Exit(1)
@@ -135,12 +136,27 @@ func createSSHConfig(syncCfg config.SyncInfo) *crypto_ssh.ClientConfig {
 		Auth: []crypto_ssh.AuthMethod{
 			auth.PasswordKey(syncCfg.SCPConfig.Username, syncCfg.SCPConfig.Password),
 		},
-		HostKeyCallback: crypto_ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback: ssh.NewHostKeyCallback(syncCfg.SCPConfig.StrictHostKey),
 	}
 }
 
 // createSCPclient 创建并连接 SCP 客户端
-func createSCPclient(remoteURI string, sshCfg *crypto_ssh.ClientConfig) (scp.Client, error) {
+// 配置了跳板节点时，先经过跳板链建立 SSH 连接，再在其上创建 SCP 会话
+func createSCPclient(remoteURI string, jumps []string, sshCfg *crypto_ssh.ClientConfig) (scp.Client, error) {
+	if len(jumps) > 0 {
+		sshClient, err := ssh.DialVia(jumps, remoteURI, sshCfg)
+		if err != nil {
+			return scp.Client{}, fmt.Errorf("couldn't establish connection to remote server: %w", err)
+		}
+		client, err := scp.NewClientBySSH(sshClient)
+		if err != nil {
+			sshClient.Close()
+			return client, fmt.Errorf("couldn't create scp session: %w", err)
+		}
+		client.Conn = sshClient
+		return client, nil
+	}
+
 	client := scp.NewClient(remoteURI, sshCfg)
 	if err := client.Connect(); err != nil {
 		return client, fmt.Errorf("couldn't establish connection to remote server: %w", err)
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// AlgorithmPolicy SSH 算法配置，可写在全局 [ssh]、节点组或节点上
+// 列表为空时使用上一级的设置，全部为空时只使用没有已知安全问题的算法
+type AlgorithmPolicy struct {
+	// Ciphers 加密算法列表，按优先顺序排列
+	Ciphers StringList `toml:"ciphers,omitempty" mapstructure:"ciphers"`
+	// KEX 密钥交换算法列表
+	KEX StringList `toml:"kex,omitempty" mapstructure:"kex"`
+	// MACs 消息认证算法列表
+	MACs StringList `toml:"macs,omitempty" mapstructure:"macs"`
+	// HostKeyAlgorithms 接受的主机密钥算法列表
+	HostKeyAlgorithms StringList `toml:"host_key_algorithms,omitempty" mapstructure:"host_key_algorithms"`
+	// Legacy 在默认算法之后追加 arcfour、3des-cbc、ssh-rsa、ssh-dss 等不安全算法，
+	// 用于只支持旧算法的交换机和设备，任意一级开启即生效
+	Legacy bool `toml:"legacy,omitempty" mapstructure:"legacy"`
+}
+
+// ModernAlgorithms 默认使用的算法，即 x/crypto/ssh 支持的算法中没有已知安全问题的部分
+func ModernAlgorithms() ssh.Algorithms {
+	return ssh.SupportedAlgorithms()
+}
+
+// LegacyAlgorithms legacy = true 时使用的算法，在默认算法之后追加不安全的算法
+func LegacyAlgorithms() ssh.Algorithms {
+	modern, insecure := ssh.SupportedAlgorithms(), ssh.InsecureAlgorithms()
+	return ssh.Algorithms{
+		Ciphers:        append(modern.Ciphers, insecure.Ciphers...),
+		KeyExchanges:   append(modern.KeyExchanges, insecure.KeyExchanges...),
+		MACs:           append(modern.MACs, insecure.MACs...),
+		HostKeys:       append(modern.HostKeys, insecure.HostKeys...),
+		PublicKeyAuths: append(modern.PublicKeyAuths, insecure.PublicKeyAuths...),
+	}
+}
+
+// Algorithms 返回节点最终使用的算法
+// 每类算法按 节点 > 节点组 > 全局 [ssh] 的顺序取第一个非空列表，都为空时按 legacy 使用默认列表
+func (c *Configs) Algorithms(n *SSHNode) ssh.Algorithms {
+	policies := []AlgorithmPolicy{}
+	if n != nil {
+		policies = append(policies, n.AlgorithmPolicy)
+	}
+	if c != nil {
+		if group := c.GroupOf(n); group != nil {
+			policies = append(policies, group.AlgorithmPolicy)
+		}
+		policies = append(policies, c.SSH.AlgorithmPolicy)
+	}
+
+	defaults := ModernAlgorithms()
+	for _, p := range policies {
+		if p.Legacy {
+			defaults = LegacyAlgorithms()
+			break
+		}
+	}
+	pick := func(get func(AlgorithmPolicy) StringList, fallback []string) []string {
+		for _, p := range policies {
+			if list := get(p); len(list) > 0 {
+				return list
+			}
+		}
+		return fallback
+	}
+	return ssh.Algorithms{
+		Ciphers:      pick(func(p AlgorithmPolicy) StringList { return p.Ciphers }, defaults.Ciphers),
+		KeyExchanges: pick(func(p AlgorithmPolicy) StringList { return p.KEX }, defaults.KeyExchanges),
+		MACs:         pick(func(p AlgorithmPolicy) StringList { return p.MACs }, defaults.MACs),
+		HostKeys:     pick(func(p AlgorithmPolicy) StringList { return p.HostKeyAlgorithms }, defaults.HostKeys),
+	}
+}
+
+// validateAlgorithms 验证算法名称都是 x/crypto/ssh 实现的算法
+func validateAlgorithms(p AlgorithmPolicy) error {
+	legacy := LegacyAlgorithms()
+	checks := []struct {
+		key   string
+		list  StringList
+		known []string
+	}{
+		{"ciphers", p.Ciphers, legacy.Ciphers},
+		// curve25519-sha256@libssh.org 是 curve25519-sha256 的旧名称
+		{"kex", p.KEX, append(legacy.KeyExchanges, "curve25519-sha256@libssh.org")},
+		{"macs", p.MACs, legacy.MACs},
+		{"host_key_algorithms", p.HostKeyAlgorithms, legacy.HostKeys},
+	}
+	for _, check := range checks {
+		for _, name := range check.list {
+			if !slices.Contains(check.known, name) {
+				return fmt.Errorf("unsupported %s algorithm: %s", check.key, name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/GuanceCloud/toml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlgorithms(t *testing.T) {
+	var cfg Configs
+	_, err := toml.Decode(`
+[ssh]
+macs = ["hmac-sha2-256"]
+
+[[nodes]]
+groups = "switches"
+legacy = true
+ssh = [
+  { name = "sw1", host = "10.0.0.1" },
+  { name = "sw2", host = "10.0.0.2", ciphers = ["aes128-cbc"] },
+]
+
+[[nodes]]
+groups = "servers"
+ssh = [
+  { name = "web", host = "10.0.1.1", kex = "curve25519-sha256" },
+]
+`, &cfg)
+	assert.NoError(t, err)
+
+	// 默认只使用没有已知安全问题的算法，列表按 节点 > 节点组 > 全局 选择
+	web := cfg.Algorithms(cfg.FindNode("web"))
+	assert.Equal(t, ModernAlgorithms().Ciphers, web.Ciphers)
+	assert.Equal(t, []string{"curve25519-sha256"}, web.KeyExchanges)
+	assert.Equal(t, []string{"hmac-sha2-256"}, web.MACs)
+	assert.NotContains(t, web.HostKeys, "ssh-rsa")
+
+	// 节点组开启 legacy 后追加不安全的算法
+	sw1 := cfg.Algorithms(cfg.FindNode("sw1"))
+	assert.Contains(t, sw1.Ciphers, "3des-cbc")
+	assert.Contains(t, sw1.HostKeys, "ssh-rsa")
+	assert.Equal(t, []string{"hmac-sha2-256"}, sw1.MACs)
+	assert.Equal(t, []string{"aes128-cbc"}, cfg.Algorithms(cfg.FindNode("sw2")).Ciphers)
+
+	// 命令行覆盖设置时使用的副本仍然使用节点组的设置
+	sw1Copy := cfg.FindNode("sw1").Copy()
+	sw1Copy.Port = 2222
+	assert.Contains(t, cfg.Algorithms(sw1Copy).Ciphers, "3des-cbc")
+	assert.Contains(t, cfg.Algorithms(sw1Copy.Copy()).Ciphers, "3des-cbc")
+	assert.Same(t, &cfg.Nodes[0], cfg.GroupOf(sw1Copy))
+
+	// 不在配置中的节点只使用全局配置
+	assert.Equal(t, ModernAlgorithms().Ciphers, cfg.Algorithms(&SSHNode{Host: "10.0.2.1"}).Ciphers)
+
+	assert.NoError(t, validateAlgorithms(cfg.Nodes[0].SSHNodes[1].AlgorithmPolicy))
+	assert.Error(t, validateAlgorithms(AlgorithmPolicy{Ciphers: StringList{"blowfish-cbc"}}))
+	assert.Error(t, validateAlgorithms(AlgorithmPolicy{HostKeyAlgorithms: StringList{"ssh-foo"}}))
+}
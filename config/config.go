@@ -68,6 +68,11 @@ func unmarshalAndValidateConfig(c *Configs) error {
 		return fmt.Errorf("mysshw:: Configuration validation failed: %v", err)
 	}
 
+	// 配置变化后重新生成 [export] ssh_config 文件
+	if _, err := c.WriteOpenSSHExport(); err != nil {
+		fmt.Fprintf(os.Stderr, "mysshw:: failed to export ssh_config: %v\n", err)
+	}
+
 	return nil
 }
 
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -19,3 +20,24 @@ func TestGetCfgPath(t *testing.T) {
 		assert.EqualError(t, err, "GetCfgPath:Error")
 	}
 }
+
+func TestKeepalive(t *testing.T) {
+	cfg := &Configs{SSH: SSHConfig{KeepaliveInterval: 30}}
+
+	interval, count := cfg.Keepalive(&SSHNode{})
+	assert.Equal(t, 30*time.Second, interval)
+	assert.Equal(t, DefaultKeepaliveCountMax, count)
+
+	// 节点配置优先于全局配置
+	interval, count = cfg.Keepalive(&SSHNode{KeepaliveInterval: 5, KeepaliveCountMax: 2})
+	assert.Equal(t, 5*time.Second, interval)
+	assert.Equal(t, 2, count)
+
+	// -1 关闭保活
+	interval, _ = cfg.Keepalive(&SSHNode{KeepaliveInterval: -1})
+	assert.Equal(t, time.Duration(0), interval)
+
+	interval, count = (&Configs{}).Keepalive(nil)
+	assert.Equal(t, DefaultKeepaliveInterval, interval)
+	assert.Equal(t, DefaultKeepaliveCountMax, count)
+}
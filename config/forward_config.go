@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Forward 端口转发规则
+type Forward struct {
+	// Listen 监听地址 host:port
+	Listen string
+	// Target 转发目标地址 host:port
+	Target string
+}
+
+func (f Forward) String() string {
+	return f.Listen + " -> " + f.Target
+}
+
+// ParseForward 解析 OpenSSH 风格的转发参数: [bind_address:]port:host:hostport
+// 本地转发(-L)的监听地址在本机，远程转发(-R)的监听地址在服务器
+// 省略 bind_address 时只监听回环地址，bind_address 为 "*" 或空时监听所有地址
+// IPv6 地址需要用方括号包裹，如 [::1]:8080:[fe80::1]:80
+func ParseForward(spec string) (Forward, error) {
+	parts, err := splitForwardSpec(spec)
+	if err != nil {
+		return Forward{}, err
+	}
+
+	var bind string
+	switch len(parts) {
+	case 3:
+		bind = "127.0.0.1"
+	case 4:
+		bind = parts[0]
+		if bind == "*" || bind == "" {
+			bind = "0.0.0.0"
+		}
+		parts = parts[1:]
+	default:
+		return Forward{}, fmt.Errorf("invalid forward spec '%s', expected [bind_address:]port:host:hostport", spec)
+	}
+
+	listenPort, host, targetPort := parts[0], parts[1], parts[2]
+	if err := checkPort(listenPort); err != nil {
+		return Forward{}, fmt.Errorf("invalid forward spec '%s': %v", spec, err)
+	}
+	if err := checkPort(targetPort); err != nil {
+		return Forward{}, fmt.Errorf("invalid forward spec '%s': %v", spec, err)
+	}
+	if host == "" {
+		return Forward{}, fmt.Errorf("invalid forward spec '%s': empty target host", spec)
+	}
+
+	return Forward{
+		Listen: net.JoinHostPort(bind, listenPort),
+		Target: net.JoinHostPort(host, targetPort),
+	}, nil
+}
+
+// ParseForwards 批量解析转发参数
+func ParseForwards(specs []string) ([]Forward, error) {
+	forwards := make([]Forward, 0, len(specs))
+	for _, spec := range specs {
+		f, err := ParseForward(spec)
+		if err != nil {
+			return nil, err
+		}
+		forwards = append(forwards, f)
+	}
+	return forwards, nil
+}
+
+// splitForwardSpec 按冒号拆分转发参数，方括号内的冒号不拆分
+func splitForwardSpec(spec string) ([]string, error) {
+	var parts []string
+	var cur strings.Builder
+	inBracket := false
+	for _, r := range spec {
+		switch {
+		case r == '[' && !inBracket:
+			inBracket = true
+		case r == ']' && inBracket:
+			inBracket = false
+		case r == ':' && !inBracket:
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if inBracket {
+		return nil, fmt.Errorf("invalid forward spec '%s': unclosed '['", spec)
+	}
+	return append(parts, cur.String()), nil
+}
+
+// checkPort 检查端口号是否合法
+func checkPort(port string) error {
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 0 || p > 65535 {
+		return fmt.Errorf("invalid port '%s'", port)
+	}
+	return nil
+}
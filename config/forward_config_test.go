@@ -0,0 +1,39 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseForward(t *testing.T) {
+	testCases := []struct {
+		spec   string
+		listen string
+		target string
+		hasErr bool
+	}{
+		{spec: "5432:db.internal:5432", listen: "127.0.0.1:5432", target: "db.internal:5432"},
+		{spec: "0.0.0.0:8080:localhost:80", listen: "0.0.0.0:8080", target: "localhost:80"},
+		{spec: "*:8080:localhost:80", listen: "0.0.0.0:8080", target: "localhost:80"},
+		{spec: "[::1]:8080:[fe80::1]:80", listen: "[::1]:8080", target: "[fe80::1]:80"},
+		{spec: "8080:localhost", hasErr: true},
+		{spec: "abc:localhost:80", hasErr: true},
+		{spec: "8080:localhost:70000", hasErr: true},
+		{spec: "8080::80", hasErr: true},
+		{spec: "[::1:8080:localhost:80", hasErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			f, err := ParseForward(tc.spec)
+			if tc.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.listen, f.Listen)
+			assert.Equal(t, tc.target, f.Target)
+		})
+	}
+}
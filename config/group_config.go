@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupPathSep 节点组路径中各级名称的分隔符，如 cn-east/prod/db
+const GroupPathSep = "/"
+
+// SplitGroupPath 将组路径分为各级名称，忽略多余的分隔符与空白
+func SplitGroupPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, GroupPathSep) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// JoinGroupPath 拼接并规范化组路径
+func JoinGroupPath(parts ...string) string {
+	return strings.Join(SplitGroupPath(strings.Join(parts, GroupPathSep)), GroupPathSep)
+}
+
+// ParentGroupPath 返回上一级路径，顶级路径返回空字符串
+func ParentGroupPath(path string) string {
+	parts := SplitGroupPath(path)
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts[:len(parts)-1], GroupPathSep)
+}
+
+// GroupPaths 返回每个节点组的完整路径，与 c.Nodes 一一对应
+// 组名本身可以是路径；设置了 parent 时路径为 parent 的路径加上组名。
+// parent 是另一个节点组的组名时使用该组的路径，否则 parent 本身就是路径；
+// parent 引用形成循环或匹配多个同名的组时返回错误
+func (c *Configs) GroupPaths() ([]string, error) {
+	paths := make([]string, len(c.Nodes))
+	// 0: 未解析 1: 解析中 2: 已解析
+	visit := make([]int, len(c.Nodes))
+	var resolve func(i int, chain []string) error
+	resolve = func(i int, chain []string) error {
+		group := &c.Nodes[i]
+		chain = append(chain, group.Groups)
+		switch visit[i] {
+		case 1:
+			return fmt.Errorf("group parent cycle: %s", strings.Join(chain, " -> "))
+		case 2:
+			return nil
+		}
+		visit[i] = 1
+		parent := JoinGroupPath(group.Parent)
+		if parent != "" {
+			var matches []int
+			for j := range c.Nodes {
+				if JoinGroupPath(c.Nodes[j].Groups) == parent {
+					matches = append(matches, j)
+				}
+			}
+			switch {
+			case len(matches) > 1:
+				return fmt.Errorf("group '%s': parent '%s' matches %d groups, use the full path", group.Groups, group.Parent, len(matches))
+			case len(matches) == 1:
+				if err := resolve(matches[0], chain); err != nil {
+					return err
+				}
+				parent = paths[matches[0]]
+			}
+		}
+		paths[i] = JoinGroupPath(parent, group.Groups)
+		visit[i] = 2
+		return nil
+	}
+	for i := range c.Nodes {
+		if err := resolve(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// cachedGroupPaths 返回验证配置时保存的各组路径，没有保存或节点组数量已变化时重新计算
+func (c *Configs) cachedGroupPaths() ([]string, error) {
+	if c.groupPaths != nil && len(c.groupPaths) == len(c.Nodes) {
+		return c.groupPaths, nil
+	}
+	return c.GroupPaths()
+}
+
+// GroupPath 返回节点组的完整路径，g 为nil时返回空字符串，parent 无法解析时返回组名
+func (c *Configs) GroupPath(g *Nodes) string {
+	if c == nil || g == nil {
+		return ""
+	}
+	if paths, err := c.cachedGroupPaths(); err == nil {
+		for i := range c.Nodes {
+			if &c.Nodes[i] == g {
+				return paths[i]
+			}
+		}
+	}
+	return JoinGroupPath(g.Groups)
+}
+
+// GroupsUnder 返回路径为 path 或在 path 之下的所有节点组，按配置顺序排列
+func (c *Configs) GroupsUnder(path string) []*Nodes {
+	paths, err := c.cachedGroupPaths()
+	if err != nil {
+		return nil
+	}
+	path = JoinGroupPath(path)
+	var groups []*Nodes
+	for i, p := range paths {
+		if path == "" || p == path || strings.HasPrefix(p, path+GroupPathSep) {
+			groups = append(groups, &c.Nodes[i])
+		}
+	}
+	return groups
+}
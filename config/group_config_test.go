@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupPaths(t *testing.T) {
+	node := func(name string) []*SSHNode { return []*SSHNode{{Name: name, Host: "10.0.0.1"}} }
+	cfg := &Configs{Nodes: []Nodes{
+		{Groups: "cn-east", SSHNodes: nil},
+		{Groups: "prod", Parent: "cn-east", SSHNodes: node("web")},
+		{Groups: "db", Parent: "prod", SSHNodes: node("db")},
+		{Groups: "cn-east/staging/ db ", SSHNodes: node("stg-db")},
+		{Groups: "cache", Parent: "us-west/prod", SSHNodes: node("cache")},
+	}}
+
+	// parent 可以是组名、未定义的路径，组名本身也可以是路径
+	paths, err := cfg.GroupPaths()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cn-east", "cn-east/prod", "cn-east/prod/db", "cn-east/staging/db", "us-west/prod/cache"}, paths)
+	assert.Equal(t, "cn-east/prod/db", cfg.GroupPath(cfg.GroupOf(cfg.FindNode("db"))))
+	assert.Equal(t, "prod", cfg.FindGroup("cn-east/prod").Groups)
+	assert.Equal(t, "db", cfg.FindGroup("/cn-east/prod/db/").Groups)
+	assert.Len(t, cfg.GroupsUnder("cn-east"), 4)
+	assert.Len(t, cfg.GroupsUnder("cn-east/prod"), 2)
+	assert.Empty(t, cfg.GroupsUnder("cn"))
+	assert.Equal(t, "cn-east/prod", ParentGroupPath("cn-east/prod/db"))
+	assert.Equal(t, "", ParentGroupPath("cn-east"))
+
+	// 只用作上级分组的节点组可以没有节点
+	assert.NoError(t, ValidateConfig(cfg))
+	// 验证后保存组路径，GroupPath 不再重新计算
+	assert.Equal(t, paths, cfg.groupPaths)
+	cfg.Nodes[2].Groups = "renamed"
+	assert.Equal(t, "cn-east/prod/db", cfg.GroupPath(&cfg.Nodes[2]))
+	cfg.Nodes[2].Groups = "db"
+
+	// parent 循环
+	cfg.Nodes[0].Parent = "db"
+	_, err = cfg.GroupPaths()
+	assert.EqualError(t, err, "group parent cycle: cn-east -> db -> prod -> cn-east")
+	assert.Error(t, ValidateConfig(cfg))
+	assert.Equal(t, "cn-east", cfg.GroupPath(&cfg.Nodes[0]))
+	cfg.Nodes[0].Parent = ""
+
+	// 完整路径重复
+	cfg.Nodes = append(cfg.Nodes, Nodes{Groups: "cn-east/prod", SSHNodes: node("web2")})
+	assert.EqualError(t, ValidateConfig(cfg), "duplicate group path 'cn-east/prod' (groups at index 1 and 5)")
+
+	// parent 匹配多个同名的组
+	cfg.Nodes[5] = Nodes{Groups: "prod", Parent: "us-west", SSHNodes: node("web2")}
+	cfg.Nodes = append(cfg.Nodes, Nodes{Groups: "api", Parent: "prod", SSHNodes: node("api")})
+	assert.EqualError(t, ValidateConfig(cfg), "group 'db': parent 'prod' matches 2 groups, use the full path")
+
+	// 没有下级分组的空节点组仍然报错
+	cfg.Nodes = []Nodes{{Groups: "empty"}}
+	assert.EqualError(t, ValidateConfig(cfg), "group 'empty' has no SSH nodes configured")
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FindNode 按节点名称或别名查找SSH节点，名称优先于别名
+// 未找到时返回nil
+func (c *Configs) FindNode(key string) *SSHNode {
+	if c == nil || key == "" {
+		return nil
+	}
+	for _, group := range c.Nodes {
+		for _, node := range group.SSHNodes {
+			if node.Name == key {
+				return node
+			}
+		}
+	}
+	for _, group := range c.Nodes {
+		for _, node := range group.SSHNodes {
+			if node.Alias == key {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
+// FindGroup 按完整路径或组名查找节点组，路径优先于组名，未找到时返回nil
+func (c *Configs) FindGroup(name string) *Nodes {
+	if c == nil || name == "" {
+		return nil
+	}
+	if paths, err := c.cachedGroupPaths(); err == nil {
+		for i, path := range paths {
+			if path == JoinGroupPath(name) {
+				return &c.Nodes[i]
+			}
+		}
+	}
+	for i := range c.Nodes {
+		if c.Nodes[i].Groups == name {
+			return &c.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// GroupOf 返回节点所在的节点组，节点不在配置中时返回nil；Copy 得到的副本返回原节点所在的组
+func (c *Configs) GroupOf(n *SSHNode) *Nodes {
+	if c == nil || n == nil {
+		return nil
+	}
+	if n.source != nil {
+		n = n.source
+	}
+	for i := range c.Nodes {
+		if slices.Contains(c.Nodes[i].SSHNodes, n) {
+			return &c.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// Copy 返回节点的副本，用于在命令行参数覆盖部分设置而不修改已加载的配置
+// 副本仍然使用原节点所在节点组的设置(如算法)
+func (n *SSHNode) Copy() *SSHNode {
+	c := *n
+	if c.source == nil {
+		c.source = n
+	}
+	return &c
+}
+
+// ParseSSHTarget 解析命令行上的连接目标: [user@]host[:port] 或 ssh://[user@]host[:port]
+// IPv6 地址带端口时写作 [::1]:2222；返回的节点以主机名为名称，未指定的用户和端口为空
+func ParseSSHTarget(target string) (*SSHNode, error) {
+	var user, host, port string
+	if strings.HasPrefix(target, "ssh://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh URL '%s': %v", target, err)
+		}
+		if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("invalid ssh URL '%s': unexpected path or query", target)
+		}
+		if u.User != nil {
+			user = u.User.Username()
+		}
+		host, port = u.Hostname(), u.Port()
+	} else {
+		hostPort := target
+		if i := strings.LastIndex(target, "@"); i >= 0 {
+			user, hostPort = target[:i], target[i+1:]
+		}
+		host = hostPort
+		// 只有一个冒号或带方括号时才有端口，其余的冒号属于 IPv6 地址
+		if strings.HasPrefix(hostPort, "[") || strings.Count(hostPort, ":") == 1 {
+			var err error
+			if host, port, err = net.SplitHostPort(hostPort); err != nil {
+				if !strings.HasPrefix(hostPort, "[") || !strings.HasSuffix(hostPort, "]") {
+					return nil, fmt.Errorf("invalid target '%s': %v", target, err)
+				}
+				host, port = hostPort[1:len(hostPort)-1], ""
+			}
+		}
+	}
+
+	if host == "" || strings.ContainsAny(host, " \t/@") {
+		return nil, fmt.Errorf("invalid target '%s': missing or invalid host", target)
+	}
+	node := &SSHNode{Name: host, Host: host, User: user}
+	if port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return nil, fmt.Errorf("invalid target '%s': invalid port '%s'", target, port)
+		}
+		node.Port = p
+	}
+	return node, nil
+}
+
+// ResolveJumps 将跳板引用解析为按连接顺序排列的节点链
+// 跳板节点自身配置的 jump 也会被递归展开，出现循环引用时返回错误
+func (c *Configs) ResolveJumps(jumps []string) ([]*SSHNode, error) {
+	return c.resolveJumps(jumps, nil)
+}
+
+// resolveJumps 递归解析跳板链，visiting 记录当前解析路径上的节点名称用于检测循环
+func (c *Configs) resolveJumps(jumps []string, visiting []string) ([]*SSHNode, error) {
+	var chain []*SSHNode
+	for _, ref := range splitJumps(jumps) {
+		hop := c.FindNode(ref)
+		if hop == nil {
+			return nil, fmt.Errorf("jump node '%s' not found", ref)
+		}
+		for _, v := range visiting {
+			if v == hop.Name {
+				return nil, fmt.Errorf("jump chain has a cycle: %s -> %s", strings.Join(visiting, " -> "), hop.Name)
+			}
+		}
+		hopChain, err := c.resolveJumps(hop.Jump, append(visiting, hop.Name))
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, hopChain...)
+		chain = append(chain, hop)
+	}
+	return chain, nil
+}
+
+// splitJumps 兼容 OpenSSH 的逗号分隔写法，如 jump = "bastion1,bastion2"
+func splitJumps(jumps []string) []string {
+	var refs []string
+	for _, j := range jumps {
+		for _, ref := range strings.Split(j, ",") {
+			if ref = strings.TrimSpace(ref); ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveJumps(t *testing.T) {
+	cfg := &Configs{Nodes: []Nodes{{
+		Groups: "Groups01",
+		SSHNodes: []*SSHNode{
+			{Name: "bastion-1", Alias: "b1", Host: "10.0.0.1"},
+			{Name: "bastion-2", Alias: "b2", Host: "10.0.0.2", Jump: StringList{"b1"}},
+			{Name: "db", Host: "10.0.1.1", Jump: StringList{"bastion-2"}},
+			{Name: "loop-a", Host: "10.0.2.1", Jump: StringList{"loop-b"}},
+			{Name: "loop-b", Host: "10.0.2.2", Jump: StringList{"loop-a"}},
+		},
+	}}}
+
+	// 按名称和别名查找
+	assert.Equal(t, "bastion-1", cfg.FindNode("b1").Name)
+	assert.Equal(t, "db", cfg.FindNode("db").Name)
+	assert.Nil(t, cfg.FindNode("missing"))
+	assert.Len(t, cfg.FindGroup("Groups01").SSHNodes, 5)
+	assert.Nil(t, cfg.FindGroup("missing"))
+
+	// 跳板节点自身的 jump 递归展开
+	chain, err := cfg.ResolveJumps(cfg.FindNode("db").Jump)
+	assert.NoError(t, err)
+	if assert.Len(t, chain, 2) {
+		assert.Equal(t, "bastion-1", chain[0].Name)
+		assert.Equal(t, "bastion-2", chain[1].Name)
+	}
+
+	// 逗号分隔写法
+	chain, err = cfg.ResolveJumps(StringList{"b1, b2"})
+	assert.NoError(t, err)
+	assert.Len(t, chain, 3)
+
+	// 未知节点与循环引用
+	_, err = cfg.ResolveJumps(StringList{"missing"})
+	assert.Error(t, err)
+	_, err = cfg.ResolveJumps(cfg.FindNode("loop-a").Jump)
+	assert.ErrorContains(t, err, "cycle")
+}
+
+func TestParseSSHTarget(t *testing.T) {
+	cases := []struct {
+		target, user, host string
+		port               int
+	}{
+		{"prod-db", "", "prod-db", 0},
+		{"root@10.0.0.5:2222", "root", "10.0.0.5", 2222},
+		{"ssh://deploy@example.com", "deploy", "example.com", 0},
+		{"ssh://example.com:2200/", "", "example.com", 2200},
+		{"admin@[::1]:2222", "admin", "::1", 2222},
+		{"[fe80::1]", "", "fe80::1", 0},
+		{"fe80::1", "", "fe80::1", 0},
+	}
+	for _, c := range cases {
+		node, err := ParseSSHTarget(c.target)
+		if assert.NoError(t, err, c.target) {
+			assert.Equal(t, c.user, node.User, c.target)
+			assert.Equal(t, c.host, node.Host, c.target)
+			assert.Equal(t, c.port, node.Port, c.target)
+		}
+	}
+
+	for _, target := range []string{"", "root@", "host:0", "host:ssh", "ssh://host/path", "a b"} {
+		_, err := ParseSSHTarget(target)
+		assert.Error(t, err, target)
+	}
+}
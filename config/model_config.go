@@ -1,6 +1,12 @@
 package config
 
-import "golang.org/x/crypto/ssh"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
 
 const CFGPATH string = "~/.mysshw.toml"
 
@@ -33,6 +39,28 @@ region = "" # 区域
 endpoint = "" # 终端节点 这个值为空，按 remote_uri 的值
 
 
+#[record] # 终端会话录制(asciicast v2), 可选; 回放: mysshw replay <file>
+#enable = true # 录制所有节点的终端会话, 默认: false; 也可以在节点上设置 record=true
+#dir = "~/.mysshw/recordings" # 录制文件保存目录
+
+#[control] # 连接复用(类似 OpenSSH ControlMaster), 可选; 查看与关闭: mysshw control ls / stop
+#enable = true # 后台保持到节点的已认证连接, 新会话复用连接跳过握手, 默认: false
+#socket = "~/.mysshw/control.sock" # 控制套接字路径
+#idle_timeout = "10m" # 连接空闲多久后关闭
+
+#[ssh] # 所有节点的默认SSH连接设置, 可选; 节点上的同名配置优先
+#keepalive_interval = 10 # 保活请求间隔(秒), 类似 ServerAliveInterval, -1 表示关闭保活
+#keepalive_count_max = 3 # 连续多少次保活无响应后断开连接, 类似 ServerAliveCountMax
+#host_ca = ["~/.ssh/host_ca.pub"] # 信任的主机证书 CA 公钥, 由其签发的主机证书无需写入 known_hosts
+#ciphers = ["aes128-gcm@openssh.com", "chacha20-poly1305@openssh.com"] # 加密算法, 默认只使用没有已知安全问题的算法
+#kex = ["curve25519-sha256"] # 密钥交换算法
+#macs = ["hmac-sha2-256-etm@openssh.com"] # 消息认证算法
+#host_key_algorithms = ["ssh-ed25519", "rsa-sha2-256"] # 主机密钥算法
+#legacy = false # 追加 3des-cbc、arcfour、ssh-rsa、ssh-dss 等不安全算法, 用于旧交换机等设备
+
+#[export] # 导出设置, 可选
+#ssh_config = "~/.ssh/config.d/mysshw" # 将所有节点导出为 OpenSSH 配置, 配置变化后自动重新生成; 在 ~/.ssh/config 中添加 Include config.d/mysshw
+
 # config example.
 # see URL: https://github.com/cnphpbb/mysshw/blob/master/readme.md#config
 [[nodes]]
@@ -52,8 +80,35 @@ host = "192.168.10.60" # 不可以空，必须
 user ="vm00" # 不可以空，必须
 port = 22  # 默认值:22, 不可以空, 如果是22端口, 可以忽略这个KEY
 password = "" # 可以空, 可选; 如果有要自己填密码，可以空
-#keypath="~/.ssh/id_rsa" # 可以空, 可选
-#passphrase="abcdefghijklmn" # 可以空, 可选
+#keypath="~/.ssh/id_rsa" # 可以空, 可选; 也可以是列表 ["~/.ssh/id_ed25519", "~/.ssh/work"], 不填时依次尝试 ~/.ssh/id_ed25519、id_ecdsa、id_rsa、id_dsa
+#passphrase="abcdefghijklmn" # 可以空, 可选; 加密私钥不填密码时, 在服务器接受该密钥后提示输入
+#strict_host_key="tofu" # 可以空, 可选; 主机密钥校验: strict || tofu || off, 默认: tofu
+#jump=["Test"] # 可以空, 可选; 跳板节点(名称或别名), 多级跳板按顺序填写
+#forwards=["5432:127.0.0.1:5432"] # 可以空, 可选; 本地端口转发, 随终端会话一起启动
+#remote_forwards=["8000:127.0.0.1:3000"] # 可以空, 可选; 远程端口转发, 随终端会话一起启动
+#use_agent=true # 可以空, 可选; 使用 ssh-agent(SSH_AUTH_SOCK) 中的密钥认证
+#agent_identity="me@laptop" # 可以空, 可选; 只提供 agent 中匹配的身份: 密钥注释 || SHA256指纹 || 公钥文件路径
+#forward_agent=true # 可以空, 可选; 转发本地 ssh-agent 到远程会话(类似 ssh -A), 默认关闭, 只对可信主机开启
+#record=true # 可以空, 可选; 录制该节点的终端会话(asciicast v2), 保存到 [record] dir
+#auto_reconnect=true # 可以空, 可选; 连接意外断开(保活无响应)时自动重连, 默认关闭
+#resume_command="tmux attach || tmux new" # 可以空, 可选; 自动重连后在新终端中执行的命令
+#keepalive_interval=15 # 可以空, 可选; 保活请求间隔(秒), 不填使用全局 [ssh] 设置, -1 表示关闭保活
+#keepalive_count_max=3 # 可以空, 可选; 连续多少次保活无响应后断开连接
+#ciphers=["aes128-cbc"] # 可以空, 可选; 算法(ciphers/kex/macs/host_key_algorithms), 不填使用节点组或全局 [ssh] 设置
+#legacy=true # 可以空, 可选; 允许不安全的旧算法, 也可以写在 [[nodes]] 组上
+#certfile="~/.ssh/id_ed25519-cert.pub" # 可以空, 可选; OpenSSH 用户证书, 默认自动使用 <keypath>-cert.pub
+#host_ca=["~/.ssh/host_ca.pub"] # 可以空, 可选; 信任的主机证书 CA 公钥, 与全局 [ssh] host_ca 合并
+#tags=["prod", "mysql"] # 可以空, 可选; 节点标签, 可在节点列表中搜索, 或用 --tags "prod && !mysql" 选择节点
+#notes="主库, 周六 02:00 维护" # 可以空, 可选; 备注, 显示在节点列表中并可搜索
+
+# 多级分组: 组名可以写成路径, 或用 parent 指定上级组的组名或路径; 节点列表中按 tab 逐级浏览
+#[[nodes]]
+#groups = "cn-east/prod/db"
+#ssh = [ { name="prod-db-1", host="10.0.1.10", user="root" } ]
+#[[nodes]]
+#groups = "cache"
+#parent = "cn-east/prod" # 完整路径为 cn-east/prod/cache
+#ssh = [ { name="prod-cache-1", host="10.0.1.20", user="root" } ]
 
 [[nodes]]
 groups = "Groups02"
@@ -70,6 +125,54 @@ type (
 		CfgDir  string   `toml:"cfg_dir" mapstructure:"cfg_dir"`
 		SyncCfg SyncInfo `toml:"sync" mapstructure:"sync"`
 		Nodes   []Nodes  `toml:"nodes" mapstructure:"nodes"`
+		// Record 终端会话录制(asciicast v2)的全局设置
+		Record RecordConfig `toml:"record,omitempty" mapstructure:"record"`
+		// Control 连接复用(类似 OpenSSH ControlMaster)的全局设置
+		Control ControlConfig `toml:"control,omitempty" mapstructure:"control"`
+		// SSH 所有节点的默认SSH连接设置，节点上的同名配置优先
+		SSH SSHConfig `toml:"ssh,omitempty" mapstructure:"ssh"`
+		// Export 将节点导出为其他工具可用的格式
+		Export ExportConfig `toml:"export,omitempty" mapstructure:"export"`
+
+		// groupPaths 验证配置时计算的各节点组完整路径，与 Nodes 一一对应
+		groupPaths []string
+	}
+
+	// ExportConfig 导出配置
+	ExportConfig struct {
+		// SSHConfig 导出 OpenSSH 配置(Host 块)的文件，每次配置变化后重新生成，可以在 ~/.ssh/config 中 Include
+		SSHConfig string `toml:"ssh_config,omitempty" mapstructure:"ssh_config"`
+	}
+
+	// SSHConfig 所有节点的默认SSH连接设置
+	SSHConfig struct {
+		// KeepaliveInterval 保活请求间隔(秒)，类似 ServerAliveInterval, 默认: 10, -1 表示关闭保活
+		KeepaliveInterval int `toml:"keepalive_interval,omitempty" mapstructure:"keepalive_interval"`
+		// KeepaliveCountMax 连续多少次保活请求无响应后断开连接，类似 ServerAliveCountMax, 默认: 3
+		KeepaliveCountMax int `toml:"keepalive_count_max,omitempty" mapstructure:"keepalive_count_max"`
+		// HostCA 信任的主机证书 CA 公钥(文件路径或公钥内容)，由这些 CA 签发的主机证书无需写入 known_hosts
+		HostCA StringList `toml:"host_ca,omitempty" mapstructure:"host_ca"`
+		// AlgorithmPolicy 所有节点默认使用的算法
+		AlgorithmPolicy `mapstructure:",squash"`
+	}
+
+	// ControlConfig 连接复用配置
+	// 开启后由后台 mysshw 进程保持到各节点的已认证连接，新的终端、exec 等会话通过本地控制套接字复用这些连接
+	ControlConfig struct {
+		// Enable 开启连接复用
+		Enable bool `toml:"enable,omitempty" mapstructure:"enable"`
+		// Socket 控制套接字路径, 默认: ~/.mysshw/control.sock
+		Socket string `toml:"socket,omitempty" mapstructure:"socket"`
+		// IdleTimeout 连接空闲多久后关闭, 如 "10m", 默认: 10m
+		IdleTimeout string `toml:"idle_timeout,omitempty" mapstructure:"idle_timeout"`
+	}
+
+	// RecordConfig 终端会话录制配置
+	RecordConfig struct {
+		// Enable 录制所有节点的终端会话
+		Enable bool `toml:"enable,omitempty" mapstructure:"enable"`
+		// Dir 录制文件(.cast)的保存目录, 默认: ~/.mysshw/recordings
+		Dir string `toml:"dir,omitempty" mapstructure:"dir"`
 	}
 
 	SyncInfo struct {
@@ -90,6 +193,10 @@ type (
 		Password   string `toml:"password" mapstructure:"password"`
 		KeyPath    string `toml:"keyPath" mapstructure:"keyPath"`
 		Passphrase string `toml:"passphrase" mapstructure:"passphrase"`
+		// StrictHostKey 主机密钥校验模式: strict || tofu || off, 默认: tofu
+		StrictHostKey string `toml:"strict_host_key,omitempty" mapstructure:"strict_host_key"`
+		// Jump 跳板节点(节点名称或别名)，按顺序依次跳转
+		Jump StringList `toml:"jump,omitempty" mapstructure:"jump"`
 	}
 	S3Config struct {
 		AccessKey  string `toml:"access_key" mapstructure:"access_key"`
@@ -99,19 +206,97 @@ type (
 		Endpoint   string `toml:"endpoint" mapstructure:"endpoint"`
 	}
 	Nodes struct {
-		Groups   string     `toml:"groups"`
+		// Groups 组名，可以写成路径表示多级分组，如 cn-east/prod/db
+		Groups string `toml:"groups"`
+		// Parent 上级节点组的组名或路径，不填时为顶级节点组
+		Parent   string     `toml:"parent,omitempty" mapstructure:"parent"`
 		SSHNodes []*SSHNode `toml:"ssh" mapstructure:"ssh"`
+		// AlgorithmPolicy 组内节点默认使用的算法
+		AlgorithmPolicy `mapstructure:",squash"`
 	}
 	SSHNode struct {
-		Name       string `toml:"name" mapstructure:"name"`
-		Alias      string `toml:"alias,omitempty" mapstructure:"alias"`
-		Host       string `toml:"host" mapstructure:"host"`
-		User       string `toml:"user,omitempty" mapstructure:"user"`
-		Port       int    `toml:"port,omitempty" mapstructure:"port"`
-		KeyPath    string `toml:"keypath,omitempty" mapstructure:"keypath"`
+		Name  string `toml:"name" mapstructure:"name"`
+		Alias string `toml:"alias,omitempty" mapstructure:"alias"`
+		Host  string `toml:"host" mapstructure:"host"`
+		User  string `toml:"user,omitempty" mapstructure:"user"`
+		Port  int    `toml:"port,omitempty" mapstructure:"port"`
+		// Tags 节点标签，可在节点列表中搜索，或用标签选择表达式(见 Selector)选择节点
+		Tags StringList `toml:"tags,omitempty" mapstructure:"tags"`
+		// Notes 节点备注，显示在节点列表中并可搜索
+		Notes string `toml:"notes,omitempty" mapstructure:"notes"`
+		// KeyPath 私钥文件，可以是单个路径或列表, 不填时依次尝试 ~/.ssh/id_ed25519、id_ecdsa、id_rsa、id_dsa
+		KeyPath StringList `toml:"keypath,omitempty" mapstructure:"keypath"`
+		// Passphrase 加密私钥的密码，不填时在需要时提示输入
 		Passphrase string `toml:"passphrase,omitempty" mapstructure:"passphrase"`
 		Password   string `toml:"password,omitempty" mapstructure:"password"`
+		// CertFile OpenSSH 用户证书文件, 不填时自动使用私钥旁的 <keypath>-cert.pub
+		CertFile string `toml:"certfile,omitempty" mapstructure:"certfile"`
+		// HostCA 信任的主机证书 CA 公钥(文件路径或公钥内容)，与全局 [ssh] host_ca 合并
+		HostCA StringList `toml:"host_ca,omitempty" mapstructure:"host_ca"`
+		// UseAgent 使用 ssh-agent(SSH_AUTH_SOCK) 中的密钥认证
+		UseAgent bool `toml:"use_agent,omitempty" mapstructure:"use_agent"`
+		// AgentIdentity 只提供 agent 中匹配的身份: 密钥注释、SHA256指纹或公钥文件路径
+		AgentIdentity string `toml:"agent_identity,omitempty" mapstructure:"agent_identity"`
+		// ForwardAgent 将本地 ssh-agent 转发到远程会话(类似 ssh -A)，默认关闭
+		ForwardAgent bool `toml:"forward_agent,omitempty" mapstructure:"forward_agent"`
+		// StrictHostKey 主机密钥校验模式: strict || tofu || off, 默认: tofu
+		StrictHostKey string `toml:"strict_host_key,omitempty" mapstructure:"strict_host_key"`
+		// Jump 跳板节点(节点名称或别名)，按顺序依次跳转, 类似 OpenSSH 的 ProxyJump
+		Jump StringList `toml:"jump,omitempty" mapstructure:"jump"`
+		// Forwards 本地端口转发(-L)，格式: [bind_address:]port:host:hostport
+		Forwards StringList `toml:"forwards,omitempty" mapstructure:"forwards"`
+		// RemoteForwards 远程端口转发(-R)，格式: [bind_address:]port:host:hostport
+		RemoteForwards StringList `toml:"remote_forwards,omitempty" mapstructure:"remote_forwards"`
+		// Record 录制该节点的终端会话(asciicast v2)，全局 [record] enable 为true时所有节点都录制
+		Record bool `toml:"record,omitempty" mapstructure:"record"`
+		// AutoReconnect 终端会话因连接断开而结束时自动重连，默认关闭
+		AutoReconnect bool `toml:"auto_reconnect,omitempty" mapstructure:"auto_reconnect"`
+		// ResumeCommand 自动重连后在新终端中执行的命令，如 "tmux attach || tmux new"
+		ResumeCommand string `toml:"resume_command,omitempty" mapstructure:"resume_command"`
+		// KeepaliveInterval 保活请求间隔(秒)，不填时使用全局 [ssh] 设置, -1 表示关闭保活
+		KeepaliveInterval int `toml:"keepalive_interval,omitempty" mapstructure:"keepalive_interval"`
+		// KeepaliveCountMax 连续多少次保活请求无响应后断开连接，不填时使用全局 [ssh] 设置
+		KeepaliveCountMax int `toml:"keepalive_count_max,omitempty" mapstructure:"keepalive_count_max"`
+		// AlgorithmPolicy 节点使用的算法(ciphers、kex、macs、host_key_algorithms、legacy)，不填时使用节点组或全局 [ssh] 设置
+		AlgorithmPolicy `mapstructure:",squash"`
+
+		// source Copy 返回的副本对应的配置中的节点，用于查找所在的节点组
+		source *SSHNode
+	}
+
+	// StringList 字符串列表，配置中既可以写成单个字符串也可以写成数组
+	StringList []string
+)
+
+// UnmarshalTOML 兼容单个字符串与字符串数组两种写法
+func (l *StringList) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		*l = StringList{v}
+	case []interface{}:
+		list := make(StringList, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected string in list, got %T", item)
+			}
+			list = append(list, str)
+		}
+		*l = list
+	default:
+		return fmt.Errorf("expected string or list of strings, got %T", data)
 	}
+	return nil
+}
+
+// 主机密钥校验模式
+const (
+	// HostKeyStrict 只接受 known_hosts 中已存在的主机密钥
+	HostKeyStrict = "strict"
+	// HostKeyTOFU 首次连接时提示确认指纹并记录(trust on first use)
+	HostKeyTOFU = "tofu"
+	// HostKeyOff 不校验主机密钥(不安全)
+	HostKeyOff = "off"
 )
 
 //type AutoGenerated struct {
@@ -156,13 +341,109 @@ func (n *SSHNode) SetPort() int {
 	return n.Port
 }
 
-func (n *SSHNode) SetKeyPath() string {
-	if n.KeyPath == "" {
-		return ""
-	}
+func (n *SSHNode) SetKeyPath() []string {
 	return n.KeyPath
 }
 
+// SetStrictHostKey 返回主机密钥校验模式，默认为 tofu
+func (n *SSHNode) SetStrictHostKey() string {
+	if n.StrictHostKey == "" {
+		return HostKeyTOFU
+	}
+	return strings.ToLower(n.StrictHostKey)
+}
+
+// DefaultRecordDir 录制文件的默认保存目录
+const DefaultRecordDir = "~/.mysshw/recordings"
+
+// RecordEnabled 返回是否录制该节点的终端会话
+func (c *Configs) RecordEnabled(n *SSHNode) bool {
+	if n != nil && n.Record {
+		return true
+	}
+	return c != nil && c.Record.Enable
+}
+
+// RecordDir 返回录制文件的保存目录
+func (c *Configs) RecordDir() string {
+	if c == nil || c.Record.Dir == "" {
+		return DefaultRecordDir
+	}
+	return c.Record.Dir
+}
+
+// DefaultControlSocket 连接复用控制套接字的默认路径
+const DefaultControlSocket = "~/.mysshw/control.sock"
+
+// DefaultControlIdleTimeout 复用连接的默认空闲超时
+const DefaultControlIdleTimeout = 10 * time.Minute
+
+// ControlSocket 返回连接复用控制套接字的路径
+func (c *Configs) ControlSocket() string {
+	if c == nil || c.Control.Socket == "" {
+		return DefaultControlSocket
+	}
+	return c.Control.Socket
+}
+
+// ControlIdleTimeout 返回复用连接的空闲超时，配置无效时使用默认值
+func (c *Configs) ControlIdleTimeout() time.Duration {
+	if c == nil || c.Control.IdleTimeout == "" {
+		return DefaultControlIdleTimeout
+	}
+	d, err := time.ParseDuration(c.Control.IdleTimeout)
+	if err != nil || d <= 0 {
+		return DefaultControlIdleTimeout
+	}
+	return d
+}
+
+// 保活默认值，与 Login 原有的保活行为一致
+const (
+	DefaultKeepaliveInterval = 10 * time.Second
+	DefaultKeepaliveCountMax = 3
+)
+
+// Keepalive 返回节点的保活间隔与最大无响应次数，节点配置优先于全局 [ssh] 配置
+// 间隔为0表示关闭保活
+func (c *Configs) Keepalive(n *SSHNode) (interval time.Duration, countMax int) {
+	seconds, count := 0, 0
+	if n != nil {
+		seconds, count = n.KeepaliveInterval, n.KeepaliveCountMax
+	}
+	if c != nil {
+		if seconds == 0 {
+			seconds = c.SSH.KeepaliveInterval
+		}
+		if count == 0 {
+			count = c.SSH.KeepaliveCountMax
+		}
+	}
+
+	interval = DefaultKeepaliveInterval
+	if seconds < 0 {
+		interval = 0
+	} else if seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
+	if count <= 0 {
+		count = DefaultKeepaliveCountMax
+	}
+	return interval, count
+}
+
+// HostCAs 返回节点信任的主机证书 CA，包括节点与全局 [ssh] 的配置
+func (c *Configs) HostCAs(n *SSHNode) []string {
+	var cas []string
+	if n != nil {
+		cas = append(cas, n.HostCA...)
+	}
+	if c != nil {
+		cas = append(cas, c.SSH.HostCA...)
+	}
+	return cas
+}
+
 func (n *SSHNode) SetPassword() ssh.AuthMethod {
 	if n.Password == "" {
 		return nil
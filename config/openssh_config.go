@@ -0,0 +1,373 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OpenSSHHost OpenSSH 配置文件(~/.ssh/config)中的一个主机
+type OpenSSHHost struct {
+	// Alias Host 行中的主机名
+	Alias    string
+	HostName string
+	User     string
+	Port     int
+	// IdentityFiles 所有匹配块中的 IdentityFile，按出现顺序排列
+	IdentityFiles []string
+	// ProxyJump 跳板主机，"none" 表示不使用跳板
+	ProxyJump []string
+}
+
+// openSSHBlock 一个 Host 块，patterns 为空表示第一个 Host 之前的全局设置
+type openSSHBlock struct {
+	patterns []string
+	options  [][2]string
+}
+
+// maxIncludeDepth Include 的最大嵌套深度，与 OpenSSH 一致
+const maxIncludeDepth = 16
+
+// ParseOpenSSHConfig 解析 OpenSSH 配置文件，返回其中定义的具体主机(不含通配符)
+// 通配符 Host 块(如 Host *、Host *.prod)中的设置作为匹配主机的默认值，同一选项先出现的值生效；
+// Include 按 OpenSSH 的规则展开，相对路径相对于 ~/.ssh；Match 块不支持，会被跳过
+func ParseOpenSSHConfig(path string) ([]OpenSSHHost, error) {
+	p := &openSSHParser{}
+	if err := p.parseFile(path, 0); err != nil {
+		return nil, err
+	}
+
+	var hosts []OpenSSHHost
+	seen := map[string]bool{}
+	for _, block := range p.blocks {
+		for _, pattern := range block.patterns {
+			if seen[pattern] || strings.ContainsAny(pattern, "*?!") {
+				continue
+			}
+			seen[pattern] = true
+			hosts = append(hosts, p.resolve(pattern))
+		}
+	}
+	return hosts, nil
+}
+
+// openSSHParser 解析状态，blocks 按文件中出现的顺序排列(Include 的内容展开在原位置)
+type openSSHParser struct {
+	blocks []*openSSHBlock
+}
+
+// current 返回当前所在的块，第一个 Host 之前的设置放在无 patterns 的块中
+func (p *openSSHParser) current() *openSSHBlock {
+	if len(p.blocks) == 0 {
+		p.blocks = append(p.blocks, &openSSHBlock{})
+	}
+	return p.blocks[len(p.blocks)-1]
+}
+
+// parseFile 解析一个配置文件
+func (p *openSSHParser) parseFile(path string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("%s: too many nested includes", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		key, args, err := splitOpenSSHLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		if key == "" {
+			continue
+		}
+		switch key {
+		case "host":
+			p.blocks = append(p.blocks, &openSSHBlock{patterns: args})
+		case "match":
+			// 不支持 Match 条件，用一个不会匹配任何主机的块忽略其中的设置
+			p.blocks = append(p.blocks, &openSSHBlock{patterns: []string{"!*"}})
+		case "include":
+			// Include 的内容属于当前块，遇到新的 Host 时结束
+			block := p.current()
+			for _, pattern := range args {
+				matches, err := filepath.Glob(includePath(pattern))
+				if err != nil {
+					return fmt.Errorf("%s:%d: %v", path, line, err)
+				}
+				for _, m := range matches {
+					if err := p.parseFile(m, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+			// 被包含的文件中开始了新的 Host 块时，后面的设置仍属于原来的块
+			if p.current() != block {
+				p.blocks = append(p.blocks, &openSSHBlock{patterns: block.patterns})
+			}
+		default:
+			if len(args) == 0 {
+				return fmt.Errorf("%s:%d: missing argument for %s", path, line, key)
+			}
+			block := p.current()
+			block.options = append(block.options, [2]string{key, strings.Join(args, " ")})
+		}
+	}
+	return scanner.Err()
+}
+
+// resolve 按 OpenSSH 的规则合并所有匹配 alias 的块
+func (p *openSSHParser) resolve(alias string) OpenSSHHost {
+	host := OpenSSHHost{Alias: alias}
+	set := map[string]bool{}
+	for _, block := range p.blocks {
+		if block.patterns != nil && !matchHostPatterns(alias, block.patterns) {
+			continue
+		}
+		for _, opt := range block.options {
+			key, value := opt[0], opt[1]
+			// IdentityFile 可以有多个，其余选项先出现的值生效
+			if key == "identityfile" {
+				host.IdentityFiles = append(host.IdentityFiles, value)
+				continue
+			}
+			if set[key] {
+				continue
+			}
+			set[key] = true
+			switch key {
+			case "hostname":
+				host.HostName = strings.ReplaceAll(value, "%h", alias)
+			case "user":
+				host.User = value
+			case "port":
+				host.Port, _ = strconv.Atoi(value)
+			case "proxyjump":
+				if !strings.EqualFold(value, "none") {
+					for _, jump := range strings.Split(value, ",") {
+						if jump = strings.TrimSpace(jump); jump != "" {
+							host.ProxyJump = append(host.ProxyJump, jump)
+						}
+					}
+				}
+			}
+		}
+	}
+	if host.HostName == "" {
+		host.HostName = alias
+	}
+	// 没有匹配的 User 时 ssh 使用当前用户登录，导入后的节点也要明确记录，否则会按 root 登录
+	if host.User == "" {
+		host.User = currentUsername()
+	}
+	return host
+}
+
+// currentUsername 返回当前用户名，Windows 上去掉 DOMAIN\ 前缀
+func currentUsername() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	name := u.Username
+	if i := strings.LastIndexByte(name, '\\'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// matchHostPatterns 判断主机名是否匹配 Host 行的模式列表
+// 任意一个模式匹配且没有被 ! 开头的模式排除时匹配
+func matchHostPatterns(host string, patterns []string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if ok, _ := filepath.Match(pattern, host); ok {
+			if negate {
+				return false
+			}
+			matched = true
+		}
+	}
+	return matched
+}
+
+// includePath 展开 Include 的路径，~ 为用户主目录，相对路径相对于 ~/.ssh
+func includePath(pattern string) string {
+	home := ""
+	if u, err := user.Current(); err == nil {
+		home = u.HomeDir
+	}
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+		return filepath.Join(home, pattern[1:])
+	}
+	if !filepath.IsAbs(pattern) {
+		return filepath.Join(home, ".ssh", pattern)
+	}
+	return pattern
+}
+
+// splitOpenSSHLine 将一行拆分为小写的关键字与参数，支持 "Key value"、"Key=value" 与双引号参数
+func splitOpenSSHLine(line string) (string, []string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil, nil
+	}
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return strings.ToLower(line), nil, nil
+	}
+	key := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+
+	var args []string
+	for rest != "" {
+		if rest[0] == '"' {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return "", nil, fmt.Errorf("unterminated quote")
+			}
+			args = append(args, rest[1:closing+1])
+			rest = rest[closing+2:]
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			if strings.HasPrefix(rest, "#") {
+				break
+			}
+			args = append(args, rest[:end])
+			rest = rest[end:]
+		}
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	return key, args, nil
+}
+
+// SSHNode 转换为 mysshw 节点，主机别名作为节点名称
+// jumps 中包含 ProxyJump 可以引用的节点名称或别名，其他跳板(如 user@host:port)无法转换，返回在 skipped 中
+func (h OpenSSHHost) SSHNode(jumps func(ref string) bool) (node *SSHNode, skipped []string) {
+	node = &SSHNode{
+		Name: h.Alias,
+		Host: h.HostName,
+		User: h.User,
+		Port: h.Port,
+	}
+	if len(h.IdentityFiles) > 0 {
+		node.KeyPath = StringList(h.IdentityFiles)
+	}
+	for _, jump := range h.ProxyJump {
+		if jumps(jump) {
+			node.Jump = append(node.Jump, jump)
+		} else {
+			skipped = append(skipped, jump)
+		}
+	}
+	return node, skipped
+}
+
+// openSSHUnsafeChars Host 模式中不能出现的字符
+var openSSHUnsafeChars = regexp.MustCompile(`[\s*?!,#"]+`)
+
+// OpenSSHHostPatterns 返回节点导出为 OpenSSH 配置时的 Host 模式: 别名在前，其次是名称
+// 名称中的空白等字符替换为 -
+func (n *SSHNode) OpenSSHHostPatterns() []string {
+	var patterns []string
+	if n.Alias != "" {
+		patterns = append(patterns, openSSHUnsafeChars.ReplaceAllString(n.Alias, "-"))
+	}
+	name := openSSHUnsafeChars.ReplaceAllString(n.Name, "-")
+	if name != "" && (len(patterns) == 0 || patterns[0] != name) {
+		patterns = append(patterns, name)
+	}
+	return patterns
+}
+
+// OpenSSHConfig 将符合 sel 的节点渲染为 OpenSSH 配置，每个节点一个 Host 块，sel 为nil时渲染所有节点
+// 跳板引用转换为跳板节点的第一个 Host 模式(跳板节点不必符合 sel)；密码无法导出，需要密码的节点由 ssh 提示输入
+func (c *Configs) OpenSSHConfig(header string, sel *Selector) string {
+	var b strings.Builder
+	for _, line := range strings.Split(header, "\n") {
+		fmt.Fprintf(&b, "# %s\n", line)
+	}
+	selected := c.Select(sel)
+	for i, group := range selected.Nodes {
+		fmt.Fprintf(&b, "\n# %s\n", selected.GroupPath(&selected.Nodes[i]))
+		for _, node := range group.SSHNodes {
+			patterns := node.OpenSSHHostPatterns()
+			if len(patterns) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "Host %s\n", strings.Join(patterns, " "))
+			fmt.Fprintf(&b, "    HostName %s\n", node.Host)
+			fmt.Fprintf(&b, "    User %s\n", openSSHQuote(node.SetUser()))
+			fmt.Fprintf(&b, "    Port %d\n", node.SetPort())
+			for _, key := range node.KeyPath {
+				fmt.Fprintf(&b, "    IdentityFile %s\n", openSSHQuote(key))
+			}
+			if node.CertFile != "" {
+				fmt.Fprintf(&b, "    CertificateFile %s\n", openSSHQuote(node.CertFile))
+			}
+			var jumps []string
+			for _, ref := range splitJumps(node.Jump) {
+				if hop := c.FindNode(ref); hop != nil {
+					if hopPatterns := hop.OpenSSHHostPatterns(); len(hopPatterns) > 0 {
+						jumps = append(jumps, hopPatterns[0])
+					}
+				}
+			}
+			if len(jumps) > 0 {
+				fmt.Fprintf(&b, "    ProxyJump %s\n", strings.Join(jumps, ","))
+			}
+			if node.ForwardAgent {
+				b.WriteString("    ForwardAgent yes\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// openSSHQuote 参数中有空白时加双引号
+func openSSHQuote(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// WriteOpenSSHExport 将节点导出到 [export] ssh_config 指定的文件，内容没有变化时不写入
+// 返回是否更新了文件；没有配置导出文件时什么也不做
+func (c *Configs) WriteOpenSSHExport() (bool, error) {
+	if c == nil || c.Export.SSHConfig == "" {
+		return false, nil
+	}
+	path, err := ExpandHomeDir(c.Export.SSHConfig)
+	if err != nil {
+		return false, err
+	}
+	content := c.OpenSSHConfig(fmt.Sprintf(OpenSSHExportHeader, CFG_PATH), nil)
+	if old, err := os.ReadFile(path); err == nil && string(old) == content {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// OpenSSHExportHeader 导出文件的头部说明
+const OpenSSHExportHeader = "Generated by mysshw from %s, do not edit: changes are overwritten\nwhen the mysshw config changes. Use it with \"Include\" in ~/.ssh/config."
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOpenSSHConfig(t *testing.T) {
+	dir := t.TempDir()
+	included := filepath.Join(dir, "work.conf")
+	assert.NoError(t, os.WriteFile(included, []byte(`
+Host db
+  HostName 10.0.1.5
+  ProxyJump bastion,other@gw.example.com:2222
+`), 0600))
+	main := filepath.Join(dir, "config")
+	assert.NoError(t, os.WriteFile(main, []byte(`
+# 全局设置
+Port 2200
+
+Host bastion b1
+    HostName=bastion.example.com
+    User "ops"
+    IdentityFile ~/.ssh/id_bastion
+
+Include `+filepath.Join(dir, "*.conf")+`
+
+Host *.prod !skip.prod
+    User deploy
+    HostName %h.internal
+
+Host web.prod skip.prod
+    Port 22
+
+Match host db
+    User ignored
+
+Host *
+    User admin
+    IdentityFile ~/.ssh/id_ed25519
+`), 0600))
+
+	hosts, err := ParseOpenSSHConfig(main)
+	assert.NoError(t, err)
+	byAlias := map[string]OpenSSHHost{}
+	var aliases []string
+	for _, h := range hosts {
+		byAlias[h.Alias] = h
+		aliases = append(aliases, h.Alias)
+	}
+	assert.Equal(t, []string{"bastion", "b1", "db", "web.prod", "skip.prod"}, aliases)
+
+	// 先出现的值生效，IdentityFile 累加
+	bastion := byAlias["bastion"]
+	assert.Equal(t, "bastion.example.com", bastion.HostName)
+	assert.Equal(t, "ops", bastion.User)
+	assert.Equal(t, 2200, bastion.Port)
+	assert.Equal(t, []string{"~/.ssh/id_bastion", "~/.ssh/id_ed25519"}, bastion.IdentityFiles)
+
+	// Include 的主机与 ProxyJump，Match 块被忽略
+	db := byAlias["db"]
+	assert.Equal(t, "10.0.1.5", db.HostName)
+	assert.Equal(t, "admin", db.User)
+	assert.Equal(t, []string{"bastion", "other@gw.example.com:2222"}, db.ProxyJump)
+
+	// 通配符默认值与 ! 排除
+	assert.Equal(t, "deploy", byAlias["web.prod"].User)
+	assert.Equal(t, "web.prod.internal", byAlias["web.prod"].HostName)
+	assert.Equal(t, "admin", byAlias["skip.prod"].User)
+	assert.Equal(t, "skip.prod", byAlias["skip.prod"].HostName)
+
+	node, skipped := db.SSHNode(func(ref string) bool { return ref == "bastion" })
+	assert.Equal(t, "db", node.Name)
+	assert.Equal(t, StringList{"bastion"}, node.Jump)
+	assert.Equal(t, []string{"other@gw.example.com:2222"}, skipped)
+
+	// 没有任何块设置 User 时使用当前用户，与 ssh 一致
+	plain := filepath.Join(dir, "plain")
+	assert.NoError(t, os.WriteFile(plain, []byte("Host plain\n    HostName 10.0.0.9\n"), 0600))
+	hosts, err = ParseOpenSSHConfig(plain)
+	assert.NoError(t, err)
+	if assert.Len(t, hosts, 1) {
+		assert.NotEmpty(t, hosts[0].User)
+		assert.Equal(t, currentUsername(), hosts[0].User)
+		node, _ := hosts[0].SSHNode(func(string) bool { return false })
+		assert.Equal(t, currentUsername(), node.User)
+	}
+}
+
+func TestOpenSSHConfigExport(t *testing.T) {
+	cfg := &Configs{Nodes: []Nodes{{
+		Groups: "Groups01",
+		SSHNodes: []*SSHNode{
+			{Name: "bastion", Alias: "b1", Host: "10.0.0.1", User: "ops", Port: 2222},
+			{Name: "prod db", Host: "10.0.1.1", KeyPath: StringList{"~/.ssh/id_ed25519", "~/My Keys/id"}, Jump: StringList{"b1"}},
+		},
+	}}}
+	dir := t.TempDir()
+	cfg.Export.SSHConfig = filepath.Join(dir, "config.d", "mysshw")
+
+	changed, err := cfg.WriteOpenSSHExport()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = cfg.WriteOpenSSHExport()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// 导出的文件可以被重新解析，跳板使用跳板节点的第一个 Host 模式
+	hosts, err := ParseOpenSSHConfig(cfg.Export.SSHConfig)
+	assert.NoError(t, err)
+	if assert.Len(t, hosts, 3) {
+		assert.Equal(t, "b1", hosts[0].Alias)
+		assert.Equal(t, "ops", hosts[0].User)
+		assert.Equal(t, 2222, hosts[0].Port)
+		assert.Equal(t, "bastion", hosts[1].Alias)
+		assert.Equal(t, "prod-db", hosts[2].Alias)
+		assert.Equal(t, "root", hosts[2].User)
+		assert.Equal(t, []string{"~/.ssh/id_ed25519", "~/My Keys/id"}, hosts[2].IdentityFiles)
+		assert.Equal(t, []string{"b1"}, hosts[2].ProxyJump)
+	}
+}
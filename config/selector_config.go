@@ -0,0 +1,182 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Selector 按节点标签选择节点的表达式，如 prod && !mysql
+// 支持 &&(与)、||(或)、!(非) 与括号，优先级从高到低为 !、&&、||
+type Selector struct {
+	text string
+	expr selectorExpr
+}
+
+// selectorExpr 表达式树的节点
+type selectorExpr interface {
+	match(tags StringList) bool
+}
+
+type (
+	tagExpr string
+	notExpr struct{ x selectorExpr }
+	andExpr struct{ x, y selectorExpr }
+	orExpr  struct{ x, y selectorExpr }
+)
+
+func (e tagExpr) match(tags StringList) bool { return slices.Contains(tags, string(e)) }
+func (e notExpr) match(tags StringList) bool { return !e.x.match(tags) }
+func (e andExpr) match(tags StringList) bool { return e.x.match(tags) && e.y.match(tags) }
+func (e orExpr) match(tags StringList) bool  { return e.x.match(tags) || e.y.match(tags) }
+
+// ParseSelector 解析标签选择表达式，空表达式返回nil(选择所有节点)
+func ParseSelector(text string) (*Selector, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	p := &selectorParser{text: text}
+	expr, err := p.parseOr()
+	if err == nil && p.peek() != "" {
+		err = p.errorf("unexpected '%s'", p.peek())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{text: strings.TrimSpace(text), expr: expr}, nil
+}
+
+// Match 判断节点的标签是否符合表达式，s 为nil时总是返回true
+func (s *Selector) Match(node *SSHNode) bool {
+	return s == nil || s.expr.match(node.Tags)
+}
+
+// String 返回原始表达式
+func (s *Selector) String() string {
+	if s == nil {
+		return ""
+	}
+	return s.text
+}
+
+// selectorParser 递归下降解析器
+type selectorParser struct {
+	text string
+	pos  int
+}
+
+// peek 返回下一个词法单元但不移动位置，到达结尾时返回空字符串
+func (p *selectorParser) peek() string {
+	rest := strings.TrimLeftFunc(p.text[p.pos:], unicode.IsSpace)
+	switch {
+	case rest == "":
+		return ""
+	case strings.HasPrefix(rest, "&&"), strings.HasPrefix(rest, "||"):
+		return rest[:2]
+	case strings.ContainsRune("!()&|", rune(rest[0])):
+		return rest[:1]
+	}
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("!()&|", r)
+	})
+	if end < 0 {
+		return rest
+	}
+	return rest[:end]
+}
+
+// next 返回下一个词法单元并移动位置
+func (p *selectorParser) next() string {
+	token := p.peek()
+	p.pos = len(p.text) - len(strings.TrimLeftFunc(p.text[p.pos:], unicode.IsSpace)) + len(token)
+	return token
+}
+
+// errorf 返回带有表达式与出错位置的错误
+func (p *selectorParser) errorf(format string, args ...any) error {
+	pos := len(p.text) - len(strings.TrimLeftFunc(p.text[p.pos:], unicode.IsSpace)) + 1
+	return fmt.Errorf("invalid tag selector '%s': %s at position %d", p.text, fmt.Sprintf(format, args...), pos)
+}
+
+// parseOr or = and { "||" and }
+func (p *selectorParser) parseOr() (selectorExpr, error) {
+	x, err := p.parseAnd()
+	for err == nil && p.peek() == "||" {
+		p.next()
+		var y selectorExpr
+		if y, err = p.parseAnd(); err == nil {
+			x = orExpr{x, y}
+		}
+	}
+	return x, err
+}
+
+// parseAnd and = unary { "&&" unary }
+func (p *selectorParser) parseAnd() (selectorExpr, error) {
+	x, err := p.parseUnary()
+	for err == nil && p.peek() == "&&" {
+		p.next()
+		var y selectorExpr
+		if y, err = p.parseUnary(); err == nil {
+			x = andExpr{x, y}
+		}
+	}
+	return x, err
+}
+
+// parseUnary unary = "!" unary | "(" or ")" | tag
+func (p *selectorParser) parseUnary() (selectorExpr, error) {
+	switch token := p.peek(); token {
+	case "!":
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x}, nil
+	case "(":
+		p.next()
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, p.errorf("missing ')'")
+		}
+		p.next()
+		return x, nil
+	case "":
+		return nil, p.errorf("missing tag")
+	case ")", "&&", "||", "&", "|":
+		return nil, p.errorf("unexpected '%s'", token)
+	default:
+		p.next()
+		return tagExpr(token), nil
+	}
+}
+
+// Select 返回只包含符合表达式的节点的配置副本，sel 为nil时返回 c 本身
+// 节点组以完整路径命名且不再有 parent，没有节点的组被去掉；节点与 c 中的是同一对象
+func (c *Configs) Select(sel *Selector) *Configs {
+	if sel == nil {
+		return c
+	}
+	selected := *c
+	selected.Nodes, selected.groupPaths = nil, nil
+	for i, group := range c.Nodes {
+		var nodes []*SSHNode
+		for _, node := range group.SSHNodes {
+			if sel.Match(node) {
+				nodes = append(nodes, node)
+			}
+		}
+		if len(nodes) > 0 {
+			group.Groups, group.Parent = c.GroupPath(&c.Nodes[i]), ""
+			group.SSHNodes = nodes
+			selected.Nodes = append(selected.Nodes, group)
+			selected.groupPaths = append(selected.groupPaths, group.Groups)
+		}
+	}
+	return &selected
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelector(t *testing.T) {
+	nodes := map[string]*SSHNode{
+		"db":     {Name: "db", Tags: StringList{"prod", "mysql"}},
+		"web":    {Name: "web", Tags: StringList{"prod", "nginx"}},
+		"worker": {Name: "worker", Tags: StringList{"staging", "k8s-worker"}},
+		"none":   {Name: "none"},
+	}
+	selected := func(expr string) []string {
+		sel, err := ParseSelector(expr)
+		assert.NoError(t, err, expr)
+		var names []string
+		for _, name := range []string{"db", "web", "worker", "none"} {
+			if sel.Match(nodes[name]) {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"db", "web", "worker", "none"}, selected(""))
+	assert.Equal(t, []string{"db", "web"}, selected("prod"))
+	assert.Equal(t, []string{"web"}, selected("prod && !mysql"))
+	assert.Equal(t, []string{"web"}, selected("prod&&!mysql"))
+	assert.Equal(t, []string{"worker", "none"}, selected("!prod"))
+	assert.Equal(t, []string{"db", "worker"}, selected("mysql || k8s-worker"))
+	// && 优先于 ||
+	assert.Equal(t, []string{"db", "worker"}, selected("staging || prod && mysql"))
+	assert.Equal(t, []string{"db"}, selected("(staging || prod) && mysql"))
+	assert.Equal(t, []string{"none"}, selected("!(prod || staging)"))
+
+	for expr, msg := range map[string]string{
+		"prod &&":       "invalid tag selector 'prod &&': missing tag at position 8",
+		"prod mysql":    "invalid tag selector 'prod mysql': unexpected 'mysql' at position 6",
+		"(prod":         "invalid tag selector '(prod': missing ')' at position 6",
+		"prod)":         "invalid tag selector 'prod)': unexpected ')' at position 5",
+		"prod & mysql":  "invalid tag selector 'prod & mysql': unexpected '&' at position 6",
+		"|| prod":       "invalid tag selector '|| prod': unexpected '||' at position 1",
+		"!":             "invalid tag selector '!': missing tag at position 2",
+		"prod || !(a|)": "invalid tag selector 'prod || !(a|)': missing ')' at position 12",
+	} {
+		_, err := ParseSelector(expr)
+		assert.EqualError(t, err, msg, expr)
+	}
+}
+
+func TestConfigsSelect(t *testing.T) {
+	db := &SSHNode{Name: "db", Tags: StringList{"prod", "mysql"}}
+	web := &SSHNode{Name: "web", Tags: StringList{"prod"}}
+	dev := &SSHNode{Name: "dev"}
+	cfg := &Configs{Nodes: []Nodes{
+		{Groups: "cn-east", SSHNodes: []*SSHNode{dev}},
+		{Groups: "prod", Parent: "cn-east", SSHNodes: []*SSHNode{db, web}},
+	}}
+
+	assert.Same(t, cfg, cfg.Select(nil))
+	sel, err := ParseSelector("prod && !mysql")
+	assert.NoError(t, err)
+	selected := cfg.Select(sel)
+	if assert.Len(t, selected.Nodes, 1) {
+		assert.Equal(t, "cn-east/prod", selected.Nodes[0].Groups)
+		assert.Empty(t, selected.Nodes[0].Parent)
+		assert.Equal(t, []*SSHNode{web}, selected.Nodes[0].SSHNodes)
+		assert.Equal(t, "cn-east/prod", selected.GroupPath(&selected.Nodes[0]))
+	}
+	// 原配置不变
+	assert.Len(t, cfg.Nodes[1].SSHNodes, 2)
+	assert.Equal(t, "prod", cfg.Nodes[1].Groups)
+}
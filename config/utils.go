@@ -78,3 +78,51 @@ func getConfigPath(cfgPath string) (string, error) {
 	CFG_PATH = path.Join(_cfgPath, _cfgFile)
 	return CFG_PATH, err
 }
+
+// ExpandHomeDir 解析路径中的波浪号和$HOME环境变量，将它们替换为用户主目录
+// 配置校验与连接时使用同一规则，~/ 与 ~\ 都可以使用
+func ExpandHomeDir(path string) (string, error) {
+	// 处理波浪号路径
+	if strings.HasPrefix(path, "~") {
+		// 获取当前用户信息
+		u, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+
+		// 替换 ~ 为用户主目录
+		if path == "~" {
+			return u.HomeDir, nil
+		} else if len(path) > 1 {
+			// 兼容不同操作系统的路径分隔符
+			if path[1] == '/' || path[1] == '\\' {
+				// 规范化路径分隔符，确保在任何操作系统上都能正确工作
+				relativePath := path[2:]
+				// 将反斜杠替换为正斜杠，然后让 filepath.Join 处理系统特定的分隔符
+				relativePath = strings.ReplaceAll(relativePath, "\\", "/")
+				return filepath.Join(u.HomeDir, relativePath), nil
+			}
+		}
+	} else if strings.HasPrefix(path, "$HOME") {
+		// 获取当前用户信息
+		u, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+
+		if path == "$HOME" {
+			return u.HomeDir, nil
+		} else if len(path) > 5 {
+			// 处理$HOME/或$HOME\开头的路径
+			if path[5] == '/' || path[5] == '\\' {
+				// 规范化路径分隔符，确保在任何操作系统上都能正确工作
+				relativePath := path[6:]
+				// 将反斜杠替换为正斜杠，然后让 filepath.Join 处理系统特定的分隔符
+				relativePath = strings.ReplaceAll(relativePath, "\\", "/")
+				return filepath.Join(u.HomeDir, relativePath), nil
+			}
+		}
+	}
+
+	return path, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/GuanceCloud/toml"
 )
@@ -20,11 +21,97 @@ func ValidateConfig(cfg *Configs) error {
 		return fmt.Errorf("no nodes configured")
 	}
 
+	// 验证多级分组
+	paths, err := validateGroupTree(cfg)
+	if err != nil {
+		return err
+	}
+
 	for i, nodeGroup := range cfg.Nodes {
-		if err := validateNodeGroup(nodeGroup, i); err != nil {
+		if err := validateNodeGroup(nodeGroup, i, hasSubgroups(paths, paths[i])); err != nil {
 			return err
 		}
 	}
+
+	// 验证跳板引用
+	if err := validateJumps(cfg); err != nil {
+		return err
+	}
+
+	// 验证全局保活配置
+	if err := validateKeepalive(cfg.SSH.KeepaliveInterval, cfg.SSH.KeepaliveCountMax); err != nil {
+		return fmt.Errorf("[ssh]: %v", err)
+	}
+	if err := validateAlgorithms(cfg.SSH.AlgorithmPolicy); err != nil {
+		return fmt.Errorf("[ssh]: %v", err)
+	}
+
+	// 验证连接复用配置
+	if cfg.Control.IdleTimeout != "" {
+		if d, err := time.ParseDuration(cfg.Control.IdleTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid control idle_timeout: %s, e.g. \"10m\" or \"1h\"", cfg.Control.IdleTimeout)
+		}
+	}
+	return nil
+}
+
+// validateGroupTree 验证 parent 引用没有循环且每个节点组的完整路径唯一，返回各组的路径
+// 验证通过时路径保存在 cfg 中，之后 GroupPath 等按下标查找，不再重新计算
+func validateGroupTree(cfg *Configs) ([]string, error) {
+	cfg.groupPaths = nil
+	for i, group := range cfg.Nodes {
+		if JoinGroupPath(group.Groups) == "" {
+			return nil, fmt.Errorf("group at index %d has empty group name", i)
+		}
+	}
+	paths, err := cfg.GroupPaths()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]int{}
+	for i, path := range paths {
+		if j, ok := seen[path]; ok {
+			return nil, fmt.Errorf("duplicate group path '%s' (groups at index %d and %d)", path, j, i)
+		}
+		seen[path] = i
+	}
+	cfg.groupPaths = paths
+	return paths, nil
+}
+
+// hasSubgroups 判断是否有节点组在 path 之下
+func hasSubgroups(paths []string, path string) bool {
+	for _, p := range paths {
+		if strings.HasPrefix(p, path+GroupPathSep) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateJumps 验证所有跳板引用都存在且没有循环
+func validateJumps(cfg *Configs) error {
+	for _, group := range cfg.Nodes {
+		for _, node := range group.SSHNodes {
+			if _, err := cfg.ResolveJumps(node.Jump); err != nil {
+				return fmt.Errorf("SSH node '%s' in group '%s': %v", node.Name, group.Groups, err)
+			}
+		}
+	}
+	if _, err := cfg.ResolveJumps(cfg.SyncCfg.SCPConfig.Jump); err != nil {
+		return fmt.Errorf("scp sync: %v", err)
+	}
+	return nil
+}
+
+// validateKeepalive 验证保活配置，间隔只允许 -1(关闭)、0(默认) 或正数
+func validateKeepalive(interval, countMax int) error {
+	if interval < -1 {
+		return fmt.Errorf("invalid keepalive_interval: %d, use seconds > 0 or -1 to disable", interval)
+	}
+	if countMax < 0 {
+		return fmt.Errorf("invalid keepalive_count_max: %d", countMax)
+	}
 	return nil
 }
 
@@ -55,6 +142,9 @@ func validateSyncConfig(sync *SyncInfo) error {
 			fmt.Println("see: https://github.com/cnphpbb/mysshw/blob/main/example/mysshw.toml")
 			return fmt.Errorf("either password, username or keyPath is required for scp sync type")
 		}
+		if !isValidHostKeyMode(sync.SCPConfig.StrictHostKey) {
+			return fmt.Errorf("invalid strict_host_key for scp sync type: %s. Supported: strict, tofu, off", sync.SCPConfig.StrictHostKey)
+		}
 	} else if strings.ToLower(sync.Type) == "s3" {
 		if sync.S3Config.AccessKey == "" {
 			return fmt.Errorf("access_key is required for s3 sync type")
@@ -81,16 +171,20 @@ func validateSyncConfig(sync *SyncInfo) error {
 	return nil
 }
 
-// validateNodeGroup 验证节点组配置
-func validateNodeGroup(group Nodes, index int) error {
+// validateNodeGroup 验证节点组配置，只用作上级分组的节点组可以没有节点
+func validateNodeGroup(group Nodes, index int, hasSubgroups bool) error {
 	if group.Groups == "" {
 		return fmt.Errorf("group at index %d has empty group name", index)
 	}
 
-	if len(group.SSHNodes) == 0 {
+	if len(group.SSHNodes) == 0 && !hasSubgroups {
 		return fmt.Errorf("group '%s' has no SSH nodes configured", group.Groups)
 	}
 
+	if err := validateAlgorithms(group.AlgorithmPolicy); err != nil {
+		return fmt.Errorf("group '%s': %v", group.Groups, err)
+	}
+
 	for i, sshNode := range group.SSHNodes {
 		if err := validateSSHNode(sshNode, group.Groups, i); err != nil {
 			return err
@@ -115,23 +209,60 @@ func validateSSHNode(node *SSHNode, group string, index int) error {
 		return fmt.Errorf("SSH node '%s' in group '%s' has invalid port: %d. Must be between 1 and 65535", node.Name, group, node.Port)
 	}
 
+	// 验证主机密钥校验模式
+	if !isValidHostKeyMode(node.StrictHostKey) {
+		return fmt.Errorf("SSH node '%s' in group '%s' has invalid strict_host_key: %s. Supported: strict, tofu, off", node.Name, group, node.StrictHostKey)
+	}
+
+	// 验证端口转发规则
+	if _, err := ParseForwards(node.Forwards); err != nil {
+		return fmt.Errorf("SSH node '%s' in group '%s' has invalid forwards: %v", node.Name, group, err)
+	}
+	if _, err := ParseForwards(node.RemoteForwards); err != nil {
+		return fmt.Errorf("SSH node '%s' in group '%s' has invalid remote_forwards: %v", node.Name, group, err)
+	}
+
+	// 验证保活配置
+	if err := validateKeepalive(node.KeepaliveInterval, node.KeepaliveCountMax); err != nil {
+		return fmt.Errorf("SSH node '%s' in group '%s': %v", node.Name, group, err)
+	}
+
+	// 验证算法配置
+	if err := validateAlgorithms(node.AlgorithmPolicy); err != nil {
+		return fmt.Errorf("SSH node '%s' in group '%s': %v", node.Name, group, err)
+	}
+
 	// // 确保至少有一种认证方式
 	// if node.Password == "" && node.KeyPath == "" {
 	// 	return fmt.Errorf("SSH node '%s' in group '%s' has no authentication method (password or keyPath)", node.Name, group)
 	// }
 
 	// 如果提供了密钥路径，检查是否存在
-	if node.KeyPath != "" {
+	for i, keyPath := range node.KeyPath {
 		// 处理路径格式，兼容Windows
-		node.KeyPath = strings.ReplaceAll(node.KeyPath, "\\", "/")
-		if _, err := os.Stat(node.KeyPath); os.IsNotExist(err) {
-			return fmt.Errorf("SSH node '%s' in group '%s' key file not found: %s", node.Name, group, node.KeyPath)
+		keyPath = strings.ReplaceAll(keyPath, "\\", "/")
+		node.KeyPath[i] = keyPath
+		expanded, err := ExpandHomeDir(keyPath)
+		if err != nil {
+			return fmt.Errorf("SSH node '%s' in group '%s' key file: %v", node.Name, group, err)
+		}
+		if _, err := os.Stat(expanded); os.IsNotExist(err) {
+			return fmt.Errorf("SSH node '%s' in group '%s' key file not found: %s", node.Name, group, keyPath)
 		}
 	}
 
 	return nil
 }
 
+// isValidHostKeyMode 检查主机密钥校验模式是否受支持，空值表示使用默认值
+func isValidHostKeyMode(mode string) bool {
+	switch strings.ToLower(mode) {
+	case "", HostKeyStrict, HostKeyTOFU, HostKeyOff:
+		return true
+	}
+	return false
+}
+
 // ValidateConfigFile 验证配置文件TOML格式是否有错
 func ValidateConfigFile(cfgPath string) error {
 	var config interface{}
@@ -0,0 +1,35 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+
+	"mysshw/auth"
+	"mysshw/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// requestAgentForwarding 将本地 ssh-agent 转发给远程会话(类似 ssh -A)
+// 失败时只给出警告，不影响终端会话
+func (c *defaultClient) requestAgentForwarding(client *ssh.Client, session *ssh.Session) {
+	socket := auth.AgentSocket()
+	if socket == "" {
+		fmt.Fprintf(os.Stderr, ForwardAgentWarnStr, "SSH_AUTH_SOCK is not set")
+		return
+	}
+
+	// 远程主机的 root 可以借用转发的 agent 登录其他主机，主机身份未经校验时尤其危险
+	if c.node.SetStrictHostKey() == config.HostKeyOff {
+		fmt.Fprintf(os.Stderr, ForwardAgentUntrustedWarnStr, c.node.Name)
+	}
+
+	if err := agent.ForwardToRemote(client, socket); err != nil {
+		fmt.Fprintf(os.Stderr, ForwardAgentWarnStr, err)
+		return
+	}
+	if err := agent.RequestAgentForwarding(session); err != nil {
+		fmt.Fprintf(os.Stderr, ForwardAgentWarnStr, err)
+	}
+}
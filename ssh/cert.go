@@ -0,0 +1,109 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// loadUserCert 读取 OpenSSH 用户证书
+// 证书已过期或尚未生效时返回错误，在连接前即可发现；文件不存在时返回的错误满足 errors.Is(err, os.ErrNotExist)
+func loadUserCert(certFile string) (*ssh.Certificate, error) {
+	path, err := expandHomeDir(certFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", certFile, err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an OpenSSH certificate", certFile)
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, fmt.Errorf("%s: not a user certificate", certFile)
+	}
+	if err := checkCertValidity(cert, time.Now()); err != nil {
+		return nil, fmt.Errorf("%s: %w", certFile, err)
+	}
+	return cert, nil
+}
+
+// checkCertValidity 检查证书在 now 时是否在有效期内
+func checkCertValidity(cert *ssh.Certificate, now time.Time) error {
+	unix := uint64(now.Unix())
+	if unix < cert.ValidAfter {
+		return fmt.Errorf("certificate is not valid until %s", certTime(cert.ValidAfter))
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity && unix >= cert.ValidBefore {
+		return fmt.Errorf("certificate expired at %s", certTime(cert.ValidBefore))
+	}
+	return nil
+}
+
+// certTime 将证书中的时间转为本地时间字符串
+func certTime(t uint64) string {
+	return time.Unix(int64(t), 0).Format(time.DateTime)
+}
+
+// loadHostCAs 解析主机证书 CA 公钥，每一项是公钥文件路径或公钥内容(authorized_keys 格式)
+func loadHostCAs(entries []string) ([]ssh.PublicKey, error) {
+	var cas []ssh.PublicKey
+	for _, entry := range entries {
+		data := []byte(entry)
+		if !strings.HasPrefix(entry, "ssh-") && !strings.HasPrefix(entry, "ecdsa-") {
+			path, err := expandHomeDir(entry)
+			if err != nil {
+				return nil, err
+			}
+			if data, err = os.ReadFile(path); err != nil {
+				return nil, fmt.Errorf("host_ca: %w", err)
+			}
+		}
+		// 文件中可以有多个公钥，每行一个
+		for len(bytes.TrimSpace(data)) > 0 {
+			pub, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+			if err != nil {
+				return nil, fmt.Errorf("host_ca %s: %w", entry, err)
+			}
+			cas = append(cas, pub)
+			data = rest
+		}
+	}
+	return cas, nil
+}
+
+// hostCertCallback 使用 ssh.CertChecker 校验由 cas 签发的主机证书
+// 主机证书的主体名必须包含连接的主机名；普通主机密钥交给 fallback(known_hosts)校验，
+// 其他 CA 签发的证书由 fallback 按证书中的主机密钥校验
+func hostCertCallback(cas []ssh.PublicKey, fallback ssh.HostKeyCallback) ssh.HostKeyCallback {
+	trusted := func(auth ssh.PublicKey) bool {
+		for _, ca := range cas {
+			if sameKey(ca, auth) {
+				return true
+			}
+		}
+		return false
+	}
+	checker := &ssh.CertChecker{
+		IsHostAuthority: func(auth ssh.PublicKey, address string) bool { return trusted(auth) },
+		HostKeyFallback: fallback,
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if cert, ok := key.(*ssh.Certificate); ok && !trusted(cert.SignatureKey) {
+			return fallback(hostname, remote, cert.Key)
+		}
+		return checker.CheckHostKey(hostname, remote, key)
+	}
+}
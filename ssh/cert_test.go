@@ -0,0 +1,137 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mysshw/config"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestSigner 生成临时的 ed25519 签名器
+func newTestSigner(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+	return signer
+}
+
+// signTestCert 用 ca 为 key 签发证书
+func signTestCert(t *testing.T, ca ssh.Signer, key ssh.PublicKey, certType uint32, principals []string, after, before time.Time) *ssh.Certificate {
+	cert := &ssh.Certificate{
+		Key:             key,
+		CertType:        certType,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(after.Unix()),
+		ValidBefore:     uint64(before.Unix()),
+	}
+	assert.NoError(t, cert.SignCert(rand.Reader, ca))
+	return cert
+}
+
+// writeTestKey 将私钥写入 dir/name，passphrase 不为空时加密
+func writeTestKey(t *testing.T, dir, name, passphrase string) (string, ssh.PublicKey) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(priv, "")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte(passphrase))
+	}
+	assert.NoError(t, err)
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+	pub, err := ssh.NewPublicKey(priv.Public())
+	assert.NoError(t, err)
+	return path, pub
+}
+
+func TestLoadIdentities(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestSigner(t)
+	now := time.Now()
+	plain, plainPub := writeTestKey(t, dir, "id_plain", "")
+	encrypted, encryptedPub := writeTestKey(t, dir, "id_encrypted", "secret")
+
+	// keypath 可以是列表，加密私钥在 batch 模式下没有密码时跳过
+	node := &config.SSHNode{KeyPath: config.StringList{plain, encrypted}}
+	signers := loadIdentities(node, true)
+	if assert.Len(t, signers, 1) {
+		assert.Equal(t, plainPub.Marshal(), signers[0].PublicKey().Marshal())
+	}
+	// 交互模式下在签名时才提示输入密码
+	signers = loadIdentities(node, false)
+	if assert.Len(t, signers, 2) {
+		assert.IsType(t, &lazySigner{}, signers[1])
+		assert.Equal(t, encryptedPub.Marshal(), signers[1].PublicKey().Marshal())
+	}
+	node.Passphrase = "secret"
+	signers = loadIdentities(node, true)
+	assert.Len(t, signers, 2)
+
+	// 自动使用 <keypath>-cert.pub，证书排在私钥之前
+	cert := signTestCert(t, ca, plainPub, ssh.UserCert, []string{"deploy"}, now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, os.WriteFile(plain+"-cert.pub", ssh.MarshalAuthorizedKey(cert), 0600))
+	signers = loadIdentities(&config.SSHNode{KeyPath: config.StringList{plain}}, true)
+	if assert.Len(t, signers, 2) {
+		assert.Equal(t, ssh.CertAlgoED25519v01, signers[0].PublicKey().Type())
+	}
+
+	// certfile 匹配列表中对应的私钥
+	encryptedCert := signTestCert(t, ca, encryptedPub, ssh.UserCert, nil, now.Add(-time.Hour), now.Add(time.Hour))
+	certFile := filepath.Join(dir, "user-cert.pub")
+	assert.NoError(t, os.WriteFile(certFile, ssh.MarshalAuthorizedKey(encryptedCert), 0600))
+	signers = loadIdentities(&config.SSHNode{KeyPath: config.StringList{plain, encrypted}, Passphrase: "secret", CertFile: certFile}, true)
+	if assert.Len(t, signers, 4) {
+		assert.Equal(t, encryptedCert.Marshal(), signers[2].PublicKey().Marshal())
+	}
+
+	// 过期或尚未生效的证书在连接前报错
+	expired := signTestCert(t, ca, plainPub, ssh.UserCert, nil, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	assert.NoError(t, os.WriteFile(certFile, ssh.MarshalAuthorizedKey(expired), 0600))
+	_, err := loadUserCert(certFile)
+	assert.ErrorContains(t, err, "expired")
+	assert.ErrorContains(t, checkCertValidity(cert, now.Add(-2*time.Hour)), "not valid until")
+	_, err = loadUserCert(filepath.Join(dir, "missing-cert.pub"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestHostCertCallback(t *testing.T) {
+	ca, other, hostKey := newTestSigner(t), newTestSigner(t), newTestSigner(t)
+	now := time.Now()
+	remote := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 22}
+
+	fallbackCalled := false
+	fallback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		fallbackCalled = true
+		assert.Equal(t, hostKey.PublicKey().Marshal(), key.Marshal(), "fallback 只收到主机密钥")
+		return nil
+	}
+	cas, err := loadHostCAs([]string{string(ssh.MarshalAuthorizedKey(ca.PublicKey()))})
+	assert.NoError(t, err)
+	callback := hostCertCallback(cas, fallback)
+
+	// CA 签发且主体名匹配的主机证书无需 known_hosts
+	cert := signTestCert(t, ca, hostKey.PublicKey(), ssh.HostCert, []string{"db.example.com"}, now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, callback("db.example.com:22", remote, cert))
+	assert.Error(t, callback("web.example.com:22", remote, cert))
+	assert.False(t, fallbackCalled)
+
+	// 普通主机密钥与其他 CA 签发的证书交给 known_hosts 校验
+	assert.NoError(t, callback("db.example.com:22", remote, hostKey.PublicKey()))
+	assert.True(t, fallbackCalled)
+	fallbackCalled = false
+	untrusted := signTestCert(t, other, hostKey.PublicKey(), ssh.HostCert, []string{"db.example.com"}, now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, callback("db.example.com:22", remote, untrusted))
+	assert.True(t, fallbackCalled)
+}
@@ -2,47 +2,35 @@ package ssh
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"net"
 	"os"
-	"os/user"
-	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"mysshw/auth"
 	"mysshw/config"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/term"
 )
 
-var (
-	DefaultCiphers = []string{
-		"aes128-ctr",
-		"aes192-ctr",
-		"aes256-ctr",
-		"aes128-gcm@openssh.com",
-		"chacha20-poly1305@openssh.com",
-		"arcfour256",
-		"arcfour128",
-		"arcfour",
-		"aes128-cbc",
-		"3des-cbc",
-		"blowfish-cbc",
-		"cast128-cbc",
-		"aes192-cbc",
-		"aes256-cbc",
-	}
-)
-
 // Client 定义SSH客户端接口
 type Client interface {
 	// Login 建立SSH连接并启动会话，sessionEndCallback在会话结束时被调用
-	Login(sessionEndCallback func())
+	// 返回是否成功打开过终端会话，连接或认证失败时为false
+	Login(sessionEndCallback func()) bool
+	// Forward 建立SSH连接并只运行节点配置的端口转发(不打开终端)，直到ctx结束或连接断开
+	Forward(ctx context.Context) error
+	// Socks 建立SSH连接并在本地运行经节点转发的 SOCKS5 代理，直到ctx结束或连接断开
+	Socks(ctx context.Context, listen string, localDNS bool) error
+	// Exec 建立SSH连接并执行远程命令，返回远程命令的退出码，不打印任何连接信息
+	Exec(ctx context.Context, command string, stdout, stderr io.Writer) (int, error)
 }
 
 // DefaultClient 默认SSH客户端实现
@@ -50,120 +38,106 @@ type Client interface {
 type defaultClient struct {
 	clientConfig *ssh.ClientConfig
 	node         *config.SSHNode
+	batch        bool // 批量模式: 不在终端提示输入密码或确认主机密钥
+	recorder     *castRecorder
 }
 
-// expandHomeDir 解析路径中的波浪号和$HOME环境变量，将它们替换为用户主目录
+// expandHomeDir 解析路径中的波浪号和$HOME环境变量，见 config.ExpandHomeDir
 func expandHomeDir(path string) (string, error) {
-	// 处理波浪号路径
-	if strings.HasPrefix(path, "~") {
-		// 获取当前用户信息
-		u, err := user.Current()
-		if err != nil {
-			return "", err
-		}
-
-		// 替换 ~ 为用户主目录
-		if path == "~" {
-			return u.HomeDir, nil
-		} else if len(path) > 1 {
-			// 兼容不同操作系统的路径分隔符
-			if path[1] == '/' || path[1] == '\\' {
-				// 规范化路径分隔符，确保在任何操作系统上都能正确工作
-				relativePath := path[2:]
-				// 将反斜杠替换为正斜杠，然后让 filepath.Join 处理系统特定的分隔符
-				relativePath = strings.ReplaceAll(relativePath, "\\", "/")
-				return filepath.Join(u.HomeDir, relativePath), nil
-			}
-		}
-	} else if strings.HasPrefix(path, "$HOME") {
-		// 获取当前用户信息
-		u, err := user.Current()
-		if err != nil {
-			return "", err
-		}
-
-		if path == "$HOME" {
-			return u.HomeDir, nil
-		} else if len(path) > 5 {
-			// 处理$HOME/或$HOME\开头的路径
-			if path[5] == '/' || path[5] == '\\' {
-				// 规范化路径分隔符，确保在任何操作系统上都能正确工作
-				relativePath := path[6:]
-				// 将反斜杠替换为正斜杠，然后让 filepath.Join 处理系统特定的分隔符
-				relativePath = strings.ReplaceAll(relativePath, "\\", "/")
-				return filepath.Join(u.HomeDir, relativePath), nil
-			}
-		}
-	}
-
-	return path, nil
+	return config.ExpandHomeDir(path)
 }
 
 // genSSHConfig 生成SSH客户端配置
-func genSSHConfig(node *config.SSHNode) *defaultClient {
+// batch 为true时只使用非交互的认证方式，未知主机密钥直接拒绝，适合并发执行
+func genSSHConfig(node *config.SSHNode, batch bool) *defaultClient {
 	if node == nil {
 		return nil
 	}
-	u, err := user.Current()
-	if err != nil {
-		fmt.Println(err)
-		return nil
-	}
-
 	var authMethods []ssh.AuthMethod
-
-	var pemBytes []byte
-	if node.KeyPath == "" {
-		pemBytes, err = os.ReadFile(filepath.Join(u.HomeDir, ".ssh", "id_rsa"))
-	} else {
-		// 处理波浪号路径, 将 ~ 替换为用户主目录;
-		// 兼容不同操作系统的路径分隔符,
-		// 主要是在Mac系统的配置文件，在Windows系统中使用不需要替换
-		keyPath, expandErr := expandHomeDir(node.KeyPath)
-		if expandErr != nil {
-			fmt.Printf("路径解析错误: %v\n", expandErr)
+	// 所有公钥放在同一个 publickey 认证方式中，
+	// ssh 客户端对同名认证方式只会尝试第一个
+	var signers []ssh.Signer
+
+	// ssh-agent 中的密钥优先于密钥文件，与 OpenSSH 的顺序一致
+	if node.UseAgent {
+		identity, _ := expandHomeDir(node.AgentIdentity)
+		agentSigners, agentErr := auth.LoadAgentSigners(identity)
+		if agentErr != nil {
+			fmt.Fprintln(os.Stderr, agentErr)
 		} else {
-			pemBytes, err = os.ReadFile(keyPath)
+			signers = append(signers, agentSigners...)
 		}
 	}
 
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		var signer ssh.Signer
-		if node.Passphrase != "" {
-			signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(node.Passphrase))
-		} else {
-			signer, err = ssh.ParsePrivateKey(pemBytes)
-		}
-		if err != nil {
-			fmt.Println(err)
-		} else {
-			authMethods = append(authMethods, ssh.PublicKeys(signer))
-		}
+	signers = append(signers, loadIdentities(node, batch)...)
+	if len(signers) > 0 {
+		authMethods = append(authMethods, ssh.PublicKeys(signers...))
 	}
 
 	password := node.SetPassword()
 
 	if password != nil {
 		authMethods = append(authMethods, password)
-	} else {
-		// 当密码为空时，提示用户输入密码
-		fmt.Print("请输入SSH密码: ")
-		var passwordStr string
-		fmt.Scanln(&passwordStr)
-		if passwordStr != "" {
-			password = ssh.Password(passwordStr)
-			authMethods = append(authMethods, password)
-		} else {
-			fmt.Println("警告: 密码认证方式不可用，将尝试其他认证方式")
+	} else if !batch {
+		// 当密码为空时，只在服务器要求密码认证时才提示输入，
+		// 使用密钥或 agent 登录的节点不再需要先输入密码
+		authMethods = append(authMethods, ssh.PasswordCallback(func() (string, error) {
+			return readPassword(fmt.Sprintf(SSHClientConnectPwdStr, node.SetUser(), node.Host))
+		}))
+	}
+
+	if !batch {
+		authMethods = append(authMethods, keyboardInteractive())
+	}
+
+	hostKeyMode := node.SetStrictHostKey()
+	hostKeyCheck := NewHostKeyCallback(hostKeyMode)
+	if batch {
+		hostKeyCheck = hostKeyCallback(hostKeyMode, nil)
+	}
+	if hostKeyMode != config.HostKeyOff {
+		// 信任 host_ca 签发的主机证书
+		cas, caErr := loadHostCAs(config.CFG.HostCAs(node))
+		if caErr != nil {
+			fmt.Fprintln(os.Stderr, caErr)
+		} else if len(cas) > 0 {
+			hostKeyCheck = hostCertCallback(cas, hostKeyCheck)
 		}
 	}
 
-	authMethods = append(authMethods, ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+	// 算法按 节点 > 节点组 > 全局 [ssh] 的配置选择，默认不使用不安全的算法
+	algorithms := config.CFG.Algorithms(node)
+	addr := net.JoinHostPort(node.Host, strconv.Itoa(node.SetPort()))
+	hostKeyAlgorithms := preferKnownHostKeys(hostKeyMode, addr, algorithms.HostKeys)
+
+	config := &ssh.ClientConfig{
+		Config: ssh.Config{
+			Ciphers:      algorithms.Ciphers,
+			KeyExchanges: algorithms.KeyExchanges,
+			MACs:         algorithms.MACs,
+		},
+		User:              node.SetUser(),
+		Auth:              authMethods,
+		HostKeyCallback:   hostKeyCheck,
+		HostKeyAlgorithms: hostKeyAlgorithms,
+		Timeout:           time.Second * 10,
+	}
+
+	config.SetDefaults()
+
+	return &defaultClient{
+		clientConfig: config,
+		node:         node,
+		batch:        batch,
+	}
+}
+
+// keyboardInteractive 在终端中回答服务器的 keyboard-interactive 提问
+func keyboardInteractive() ssh.AuthMethod {
+	return ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
 		answers := make([]string, 0, len(questions))
 		for i, q := range questions {
-			fmt.Print(q)
+			fmt.Fprint(os.Stderr, q)
 			if echos[i] {
 				scan := bufio.NewScanner(os.Stdin)
 				if scan.Scan() {
@@ -178,121 +152,161 @@ func genSSHConfig(node *config.SSHNode) *defaultClient {
 				if err != nil {
 					return nil, err
 				}
-				fmt.Println()
+				fmt.Fprintln(os.Stderr)
 				answers = append(answers, string(b))
 			}
 		}
 		return answers, nil
-	}))
+	})
+}
 
-	config := &ssh.ClientConfig{
-		User:            node.SetUser(),
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         time.Second * 10,
+// readPassword 在终端提示并读取密码(不回显)，提示信息输出到 stderr
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
 	}
+	return string(b), nil
+}
 
-	config.SetDefaults()
-	config.Ciphers = append(config.Ciphers, DefaultCiphers...)
+// NewClient 创建SSH客户端
+func NewClient(node *config.SSHNode) Client {
+	return genSSHConfig(node, false)
+}
 
-	return &defaultClient{
-		clientConfig: config,
-		node:         node,
+// NewBatchClient 创建非交互的SSH客户端，用于并发批量执行
+// 不提示输入密码，也不提示确认未知主机密钥
+func NewBatchClient(node *config.SSHNode) Client {
+	return genSSHConfig(node, true)
+}
+
+// connect 建立到节点的SSH连接，配置了跳板时经过跳板链
+// 开启连接复用时优先通过控制套接字复用已有连接
+func (c *defaultClient) connect() (*ssh.Client, error) {
+	// 开启连接复用时优先复用后台进程中的已认证连接
+	if c.controlEnabled() {
+		if client, err := dialControl(c.node); err == nil {
+			return client, nil
+		}
 	}
+	return c.dial()
 }
 
-// NewClient 创建SSH客户端
-func NewClient(node *config.SSHNode) Client {
-	return genSSHConfig(node)
+// dial 直接建立到节点的SSH连接，不经过连接复用
+// 服务器只剩密码认证可用时，在终端提示输入密码后重试一次
+func (c *defaultClient) dial() (*ssh.Client, error) {
+	addr := net.JoinHostPort(c.node.Host, strconv.Itoa(c.node.SetPort()))
+	client, err := dialVia(c.node.Jump, addr, c.clientConfig, c.batch)
+	if err == nil || c.batch {
+		return client, err
+	}
+
+	msg := err.Error()
+	// use terminal password retry
+	if !strings.Contains(msg, "no supported methods remain") || strings.Contains(msg, "password") {
+		return nil, err
+	}
+	p, readPasswordErr := readPassword(fmt.Sprintf(SSHClientConnectPwdStr, c.clientConfig.User, c.node.Host))
+	if readPasswordErr != nil {
+		return nil, err
+	}
+	if p != "" {
+		c.clientConfig.Auth = append(c.clientConfig.Auth, ssh.Password(p))
+	}
+	return dialVia(c.node.Jump, addr, c.clientConfig, c.batch)
 }
 
 // Login 建立SSH连接并启动会话，sessionEndCallback在会话结束时被调用
-func (c *defaultClient) Login(sessionEndCallback func()) {
+// 节点开启 auto_reconnect 时，连接意外断开后自动重连并重新打开终端
+// 返回是否成功打开过终端会话，调用方据此只为真正建立的会话记录历史
+func (c *defaultClient) Login(sessionEndCallback func()) (established bool) {
 	if c == nil {
 		if sessionEndCallback != nil {
 			sessionEndCallback()
 		}
-		return
+		return false
 	}
-	host := c.node.Host
-	port := strconv.Itoa(c.node.SetPort())
-	//jNodes := c.node.Jump
-
-	var client *ssh.Client
+	defer func() {
+		if sessionEndCallback != nil {
+			sessionEndCallback()
+		}
+	}()
 
-	client1, err := ssh.Dial("tcp", net.JoinHostPort(host, port), c.clientConfig)
-	client = client1
+	client, err := c.connect()
 	if err != nil {
-		msg := err.Error()
-		// use terminal password retry
-		if strings.Contains(msg, "no supported methods remain") && !strings.Contains(msg, "password") {
-			fmt.Printf(SSHClientConnectPwdStr, c.clientConfig.User, host)
-			var b []byte
-			var readPasswordErr error
-
-			b, readPasswordErr = term.ReadPassword(int(syscall.Stdin))
-			if readPasswordErr == nil {
-
-				p := string(b)
-				if p != "" {
-					c.clientConfig.Auth = append(c.clientConfig.Auth, ssh.Password(p))
-				}
-				fmt.Println()
-				clientC, errclientC := ssh.Dial("tcp", net.JoinHostPort(host, port), c.clientConfig)
-				if errclientC != nil {
-					fmt.Println(errclientC)
-					if sessionEndCallback != nil {
-						sessionEndCallback()
-					}
-					return
-				}
-				client = clientC
-			}
+		fmt.Println(err)
+		return false
+	}
+	fmt.Printf(SSHConnectInfoStr, c.node.SetPort(), c.node.SetUser(), c.node.Host, string(client.ServerVersion()))
+
+	// 按配置录制终端会话，重连后继续写入同一个文件
+	if config.CFG.RecordEnabled(c.node) {
+		w, h, _ := term.GetSize(terminalFd())
+		recorder, err := newCastRecorder(config.CFG.RecordDir(), c.node, w, h)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, RecordWarnStr, err)
+		} else {
+			defer func() {
+				recorder.Close()
+				fmt.Printf(RecordSavedStr, recorder.path)
+			}()
+			fmt.Printf(RecordInfoStr, recorder.path)
+			c.recorder = recorder
 		}
 	}
-	if err != nil {
-		fmt.Println(err)
-		if sessionEndCallback != nil {
-			sessionEndCallback()
+
+	resume := false
+	for {
+		started, lost := c.shell(client, resume)
+		established = established || started
+		client.Close()
+		if !lost {
+			return established
+		}
+		fmt.Printf(SSHConnectionLostStr, c.node.Host)
+		if !c.node.AutoReconnect {
+			return established
 		}
-		return
+		if client = c.reconnect(); client == nil {
+			return established
+		}
+		resume = true
 	}
-	//}
-	defer client.Close()
+}
 
-	fmt.Printf(SSHConnectInfoStr, c.node.SetPort(), c.node.SetUser(), host, string(client.ServerVersion()))
+// shell 在已建立的连接上打开终端会话，直到会话结束
+// started 表示终端会话是否已经启动，lost 表示会话是否因连接断开而结束；resume 为true时在新终端中执行节点的 resume_command
+func (c *defaultClient) shell(client *ssh.Client, resume bool) (started, lost bool) {
+	// 端口转发、保活等随终端会话一起启动，会话结束时停止
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.startForwards(ctx, client)
 
 	session, err := client.NewSession()
 	if err != nil {
 		fmt.Println(err)
-		if sessionEndCallback != nil {
-			sessionEndCallback()
-		}
-		return
+		return false, false
 	}
 	defer session.Close()
-	defer func() {
-		if sessionEndCallback != nil {
-			sessionEndCallback()
-		}
-	}()
+
+	if c.node.ForwardAgent {
+		c.requestAgentForwarding(client, session)
+	}
 
 	fd := int(os.Stdin.Fd())
 	state, err := term.MakeRaw(fd)
 	if err != nil {
 		fmt.Println(err)
-		return
+		return false, false
 	}
 	defer term.Restore(fd, state)
 
-	//OS:windows
-	if runtime.GOOS == "windows" {
-		fd = int(os.Stdout.Fd())
-	}
-	w, h, err := term.GetSize(fd)
+	w, h, err := term.GetSize(terminalFd())
 	if err != nil {
 		fmt.Println(err)
-		return
+		return false, false
 	}
 
 	modes := ssh.TerminalModes{
@@ -303,65 +317,93 @@ func (c *defaultClient) Login(sessionEndCallback func()) {
 	err = session.RequestPty("xterm", h, w, modes)
 	if err != nil {
 		fmt.Println(err)
-		return
+		return false, false
 	}
 
 	session.Stdout = os.Stdout
 	session.Stderr = os.Stderr
+	if c.recorder != nil {
+		session.Stdout = io.MultiWriter(os.Stdout, c.recorder)
+		session.Stderr = io.MultiWriter(os.Stderr, c.recorder)
+	}
 	stdinPipe, err := session.StdinPipe()
 	if err != nil {
 		fmt.Println(err)
-		return
+		return false, false
 	}
 
 	err = session.Shell()
 	if err != nil {
 		fmt.Println(err)
-		return
+		return false, false
+	}
+	started = true
+	if resume && c.node.ResumeCommand != "" {
+		fmt.Fprintf(stdinPipe, "%s\n", c.node.ResumeCommand)
 	}
 
 	// change stdin to user
-	go func() {
-		_, err = io.Copy(stdinPipe, os.Stdin)
-		// 忽略EOF错误，因为这是正常的会话结束情况
-		if err != nil && err != io.EOF {
-			fmt.Println(err)
-		}
-		session.Close()
-	}()
+	detach, inputClosed, err := attachTerminalInput(session, stdinPipe)
+	if err != nil {
+		fmt.Println(err)
+		return started, false
+	}
+	defer detach()
 
 	// interval get terminal size
 	// fix resize issue
-	go func() {
-		var (
-			ow = w
-			oh = h
-		)
-		for {
-			cw, ch, err := term.GetSize(fd)
-			if err != nil {
-				break
-			}
+	go c.watchWindowSize(ctx, session, w, h)
 
-			if cw != ow || ch != oh {
-				err = session.WindowChange(ch, cw)
-				if err != nil {
-					break
-				}
-				ow = cw
-				oh = ch
-			}
-			time.Sleep(time.Second)
-		}
+	// send keepalive, 连续多次没有响应时关闭连接
+	dead := c.startKeepalive(ctx, client)
+	// 连接断开时 client.Wait 返回，shell 返回后由 Login 关闭连接
+	transportDone := make(chan struct{})
+	go func() {
+		client.Wait()
+		close(transportDone)
 	}()
 
-	// send keepalive
-	go func() {
-		for {
-			time.Sleep(time.Second * 10)
-			client.SendRequest("keepalive@openssh.com", false, nil)
+	err = session.Wait()
+	select {
+	case <-dead:
+		return started, true
+	default:
+	}
+	return started, sessionLost(err, inputClosed, transportDone)
+}
+
+// watchWindowSize 定时检查终端大小，变化时通知远程终端并写入录制文件
+func (c *defaultClient) watchWindowSize(ctx context.Context, session *ssh.Session, w, h int) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
 		}
-	}()
+		cw, ch, err := term.GetSize(terminalFd())
+		if err != nil {
+			return
+		}
+		if cw == w && ch == h {
+			continue
+		}
+		if err := session.WindowChange(ch, cw); err != nil {
+			return
+		}
+		if c.recorder != nil {
+			c.recorder.Resize(cw, ch)
+		}
+		w, h = cw, ch
+	}
+}
 
-	session.Wait()
+// terminalFd 返回用于获取终端大小的文件描述符
+func terminalFd() int {
+	//OS:windows
+	if runtime.GOOS == "windows" {
+		return int(os.Stdout.Fd())
+	}
+	return int(os.Stdin.Fd())
 }
@@ -2,9 +2,11 @@ package ssh
 
 import (
 	"fmt"
+	"os"
+
 	"mysshw/config"
+	"mysshw/state"
 
-	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -27,82 +29,16 @@ var (
 
 // Choose 交互式选择SSH节点
 // 参数 trees 是配置文件中的所有节点组
+// 所有组的节点显示在同一个列表中并支持模糊搜索，按 tab 切换为按多级分组逐级浏览；
+// 收藏与最近连接的节点显示在列表顶部，按 ctrl+s 收藏或取消收藏
 // 返回选中的SSH节点，用户取消操作时返回nil
 func Choose(trees *config.Configs) *config.SSHNode {
-	// 选择节点组
-	groups := make([]string, len(trees.Nodes))
-	for i, node := range trees.Nodes {
-		groups[i] = node.Groups
-	}
-
-	// 转换为huh.Option[int]类型
-	groupOptions := make([]huh.Option[int], len(groups))
-	for i, group := range groups {
-		groupOptions[i] = huh.NewOption(group, i)
-	}
-
-	var selectedGroupIndex int
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[int]().
-				Title(MsgSelectNodeGroup).
-				//Description(MsgSelectDesc).
-				Options(groupOptions...).
-				Value(&selectedGroupIndex),
-		),
-	)
-
-	err := form.Run()
+	st, err := state.Load()
 	if err != nil {
-		// 处理用户取消操作
-		if err.Error() == errFormRunError {
-			fmt.Println(MsgPrintLnStr)
-		}
-		return nil
-	}
-
-	// 选择SSH节点
-	cTrees := trees.Nodes[selectedGroupIndex].SSHNodes
-
-	// 创建带返回上级选项的节点列表
-	nodesWithParent := make([]*config.SSHNode, len(cTrees)+1)
-	nodesWithParent[0] = &config.SSHNode{Name: NodeParentName, Alias: NodeParentAlias}
-	copy(nodesWithParent[1:], cTrees)
-
-	nodeOptions := make([]huh.Option[int], len(nodesWithParent))
-	for i, node := range nodesWithParent {
-		name := node.Name
-		if i == 0 {
-			// 特殊处理返回上级选项
-			name = parentStyle.Render(name + " " + node.Alias)
-		} else {
-			if node.Alias != "" {
-				name += yellowStyle.Render("(" + node.Alias + ")")
-			}
-			if node.Host != "" {
-				userHost := ""
-				if node.User != "" {
-					userHost += blueStyle.Render(node.User + "@")
-				}
-				userHost += blueStyle.Render(node.Host)
-				name += " " + userHost
-			}
-		}
-		nodeOptions[i] = huh.NewOption(name, i)
+		fmt.Fprintf(os.Stderr, MsgStateWarnStr, err)
+		st = nil
 	}
-
-	var selectedNodeIndex int
-	nodeForm := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[int]().
-				Title(MsgSelectNode).
-				//Description(MsgSelectDesc).
-				Options(nodeOptions...).
-				Value(&selectedNodeIndex),
-		),
-	)
-
-	err = nodeForm.Run()
+	node, err := runPicker(trees, st)
 	if err != nil {
 		// 处理用户取消操作
 		if err.Error() == errFormRunError {
@@ -110,9 +46,5 @@ func Choose(trees *config.Configs) *config.SSHNode {
 		}
 		return nil
 	}
-
-	if nodesWithParent[selectedNodeIndex].Name == NodeParentName {
-		return Choose(trees)
-	}
-	return nodesWithParent[selectedNodeIndex]
+	return node
 }
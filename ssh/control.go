@@ -0,0 +1,476 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"mysshw/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// 控制套接字上的约定
+// 本地客户端通过控制套接字与后台进程建立一条 SSH 连接，用户名为节点名称，
+// 后台进程把这条连接上的通道和请求转发到该节点的已认证连接上。
+const (
+	// controlUser 管理连接使用的用户名，用于 ls/stop 请求
+	controlUser = "-control-"
+	// controlServerVersion 后台进程的 SSH 版本标识
+	controlServerVersion = "SSH-2.0-mysshw_control"
+	// controlListRequest 列出复用连接的全局请求
+	controlListRequest = "list@mysshw"
+	// controlStopRequest 关闭复用连接的全局请求，负载为节点名称，为空时关闭后台进程
+	controlStopRequest = "stop@mysshw"
+)
+
+// ControlMasterInfo 复用连接的状态，用于 mysshw control ls
+type ControlMasterInfo struct {
+	Node      string    `json:"node"`
+	Addr      string    `json:"addr"`
+	Clients   int       `json:"clients"`
+	Connected time.Time `json:"connected"`
+	LastUsed  time.Time `json:"last_used"`
+}
+
+// controlMaster 到一个节点的已认证连接
+type controlMaster struct {
+	node      *config.SSHNode
+	client    *ssh.Client
+	clients   int // 正在复用此连接的本地连接数
+	connected time.Time
+	lastUsed  time.Time
+	idle      *time.Timer
+	done      chan struct{} // 节点连接断开时关闭
+}
+
+// ControlServer 连接复用后台进程
+// 在控制套接字上接受本地连接，按节点保持已认证的 ssh.Client 并复用给新会话，
+// 连接空闲超过 idleTimeout 后关闭；没有任何连接时后台进程在 idleTimeout 后退出
+type ControlServer struct {
+	socket       string
+	idleTimeout  time.Duration
+	serverConfig *ssh.ServerConfig
+
+	mu       sync.Mutex
+	masters  map[string]*controlMaster
+	conns    int
+	exit     *time.Timer
+	listener net.Listener
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewControlServer 创建连接复用后台进程
+func NewControlServer(socket string, idleTimeout time.Duration) (*ControlServer, error) {
+	socket, err := expandHomeDir(socket)
+	if err != nil {
+		return nil, err
+	}
+	// 后台进程只监听属主可访问的本地套接字，每次启动生成临时主机密钥
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ControlServer{
+		socket:      socket,
+		idleTimeout: idleTimeout,
+		masters:     make(map[string]*controlMaster),
+		done:        make(chan struct{}),
+	}
+	s.serverConfig = &ssh.ServerConfig{
+		ServerVersion:        controlServerVersion,
+		NoClientAuth:         true,
+		NoClientAuthCallback: s.authorize,
+	}
+	s.serverConfig.AddHostKey(hostKey)
+	return s, nil
+}
+
+// Serve 监听控制套接字并处理连接，直到空闲退出或收到停止请求
+func (s *ControlServer) Serve() error {
+	if err := os.MkdirAll(filepath.Dir(s.socket), 0700); err != nil {
+		return fmt.Errorf("failed to create control socket directory: %w", err)
+	}
+	// 已有后台进程在运行时直接退出，残留的套接字文件则删除
+	if conn, err := net.Dial("unix", s.socket); err == nil {
+		conn.Close()
+		return fmt.Errorf("control socket %s is already in use", s.socket)
+	}
+	os.Remove(s.socket)
+
+	// 控制连接不做认证，套接字设置为只有属主可访问后才出现在 s.socket
+	l, err := listenControlSocket(s.socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	s.listener = l
+	defer os.Remove(s.socket)
+
+	s.mu.Lock()
+	s.checkIdleLocked()
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Shutdown 关闭所有复用连接并停止后台进程
+func (s *ControlServer) Shutdown() {
+	s.once.Do(func() {
+		close(s.done)
+		if s.listener != nil {
+			s.listener.Close()
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for name, m := range s.masters {
+			m.client.Close()
+			delete(s.masters, name)
+		}
+	})
+}
+
+// authorize 在握手阶段建立到节点的连接，失败时本地客户端认证失败并改为直接连接
+func (s *ControlServer) authorize(meta ssh.ConnMetadata) (*ssh.Permissions, error) {
+	if meta.User() == controlUser {
+		return nil, nil
+	}
+	if _, err := s.master(meta.User()); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// master 返回到节点的复用连接，不存在时使用非交互方式建立
+func (s *ControlServer) master(name string) (*controlMaster, error) {
+	s.mu.Lock()
+	if m, ok := s.masters[name]; ok {
+		s.mu.Unlock()
+		return m, nil
+	}
+	s.mu.Unlock()
+
+	node := config.CFG.FindNode(name)
+	if node == nil {
+		return nil, fmt.Errorf("SSH node '%s' not found in config", name)
+	}
+	c := genSSHConfig(node, true)
+	if c == nil {
+		return nil, fmt.Errorf("SSH node '%s': failed to build client config", name)
+	}
+	client, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.masters[name]; ok {
+		// 并发建立了两条连接，保留先建立的一条
+		client.Close()
+		return m, nil
+	}
+	now := time.Now()
+	m := &controlMaster{node: node, client: client, connected: now, lastUsed: now, done: make(chan struct{})}
+	s.masters[name] = m
+	s.startIdleLocked(m)
+	// 保活检测随主连接结束
+	ctx, cancel := context.WithCancel(context.Background())
+	c.startKeepalive(ctx, client)
+	go func() {
+		client.Wait()
+		cancel()
+		close(m.done)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.masters[name] == m {
+			delete(s.masters, name)
+			s.checkIdleLocked()
+		}
+	}()
+	return m, nil
+}
+
+// attach 增加复用连接的使用计数
+func (s *ControlServer) attach(name string) (*controlMaster, error) {
+	for {
+		m, err := s.master(name)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		if s.masters[name] != m {
+			// 连接刚好因空闲或断开被关闭，重新建立
+			s.mu.Unlock()
+			continue
+		}
+		m.clients++
+		m.lastUsed = time.Now()
+		if m.idle != nil {
+			m.idle.Stop()
+			m.idle = nil
+		}
+		s.mu.Unlock()
+		return m, nil
+	}
+}
+
+// detach 减少复用连接的使用计数，不再使用时开始空闲计时
+func (s *ControlServer) detach(m *controlMaster) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m.clients--
+	m.lastUsed = time.Now()
+	if m.clients == 0 && s.masters[m.node.Name] == m {
+		s.startIdleLocked(m)
+	}
+}
+
+// startIdleLocked 开始复用连接的空闲计时，调用方需持有 s.mu
+func (s *ControlServer) startIdleLocked(m *controlMaster) {
+	if m.clients > 0 {
+		return
+	}
+	m.idle = time.AfterFunc(s.idleTimeout, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if m.clients == 0 && s.masters[m.node.Name] == m {
+			delete(s.masters, m.node.Name)
+			m.client.Close()
+			s.checkIdleLocked()
+		}
+	})
+}
+
+// checkIdleLocked 没有复用连接和本地连接时开始后台进程的退出计时，调用方需持有 s.mu
+func (s *ControlServer) checkIdleLocked() {
+	if s.exit != nil {
+		s.exit.Stop()
+		s.exit = nil
+	}
+	if len(s.masters) > 0 || s.conns > 0 {
+		return
+	}
+	s.exit = time.AfterFunc(s.idleTimeout, func() {
+		s.mu.Lock()
+		idle := len(s.masters) == 0 && s.conns == 0
+		s.mu.Unlock()
+		if idle {
+			s.Shutdown()
+		}
+	})
+}
+
+// serveConn 处理一条本地连接
+func (s *ControlServer) serveConn(conn net.Conn) {
+	s.mu.Lock()
+	s.conns++
+	s.checkIdleLocked()
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.conns--
+		s.checkIdleLocked()
+		s.mu.Unlock()
+	}()
+
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, s.serverConfig)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sconn.Close()
+
+	if sconn.User() == controlUser {
+		go rejectChannels(chans)
+		s.handleControlRequests(reqs)
+		return
+	}
+
+	m, err := s.attach(sconn.User())
+	if err != nil {
+		return
+	}
+	defer s.detach(m)
+
+	// 节点连接断开时关闭本地连接
+	closed := make(chan struct{})
+	defer close(closed)
+	go func() {
+		select {
+		case <-m.done:
+			sconn.Close()
+		case <-closed:
+		}
+	}()
+	go s.relayGlobalRequests(m.client, reqs)
+	for nc := range chans {
+		go relayChannel(m.client, nc)
+	}
+}
+
+// handleControlRequests 处理 ls/stop 管理请求
+func (s *ControlServer) handleControlRequests(reqs <-chan *ssh.Request) {
+	for req := range reqs {
+		switch req.Type {
+		case controlListRequest:
+			b, _ := json.Marshal(s.list())
+			req.Reply(true, b)
+		case controlStopRequest:
+			name := string(req.Payload)
+			if name == "" {
+				req.Reply(true, nil)
+				s.Shutdown()
+				return
+			}
+			s.mu.Lock()
+			m, ok := s.masters[name]
+			if ok {
+				delete(s.masters, name)
+				m.client.Close()
+				s.checkIdleLocked()
+			}
+			s.mu.Unlock()
+			req.Reply(ok, nil)
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// list 返回按节点名称排序的复用连接状态
+func (s *ControlServer) list() []ControlMasterInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]ControlMasterInfo, 0, len(s.masters))
+	for _, m := range s.masters {
+		infos = append(infos, ControlMasterInfo{
+			Node:      m.node.Name,
+			Addr:      fmt.Sprintf("%s@%s:%d", m.node.SetUser(), m.node.Host, m.node.SetPort()),
+			Clients:   m.clients,
+			Connected: m.connected,
+			LastUsed:  m.lastUsed,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Node < infos[j].Node })
+	return infos
+}
+
+// relayGlobalRequests 将本地连接的保活请求转发到节点连接，其余全局请求(如远程端口转发)不支持复用
+func (s *ControlServer) relayGlobalRequests(upstream *ssh.Client, reqs <-chan *ssh.Request) {
+	for req := range reqs {
+		if req.Type != "keepalive@openssh.com" {
+			req.Reply(false, nil)
+			continue
+		}
+		ok, payload, err := upstream.SendRequest(req.Type, req.WantReply, req.Payload)
+		if err != nil {
+			// 节点连接已断开，保活失败由客户端处理
+			continue
+		}
+		req.Reply(ok, payload)
+	}
+}
+
+// relayChannel 在节点连接上打开同类型的通道，并双向转发数据和通道请求
+func relayChannel(upstream *ssh.Client, nc ssh.NewChannel) {
+	up, upReqs, err := upstream.OpenChannel(nc.ChannelType(), nc.ExtraData())
+	if err != nil {
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) {
+			nc.Reject(openErr.Reason, openErr.Message)
+		} else {
+			nc.Reject(ssh.ConnectionFailed, err.Error())
+		}
+		return
+	}
+	local, localReqs, err := nc.Accept()
+	if err != nil {
+		up.Close()
+		return
+	}
+
+	go func() {
+		io.Copy(up, local)
+		up.CloseWrite()
+	}()
+	// 本地请求的回复送达前不关闭本地通道，否则短命令的 exit-status 与关闭
+	// 可能先于 exec 的回复到达客户端，客户端只会看到 EOF
+	var replying sync.RWMutex
+	go func() {
+		// 本地关闭通道时关闭节点上的通道
+		forwardChannelRequests(up, localReqs, &replying)
+		up.Close()
+	}()
+
+	// 节点通道的数据和请求(如 exit-status)全部转发完后再关闭本地通道
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		io.Copy(local, up)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(local.Stderr(), up.Stderr())
+	}()
+	go func() {
+		defer wg.Done()
+		forwardChannelRequests(local, upReqs, nil)
+	}()
+	wg.Wait()
+	replying.Lock()
+	local.CloseWrite()
+	local.Close()
+	replying.Unlock()
+}
+
+// forwardChannelRequests 将通道请求转发到 dst，并把结果回复给请求方
+// replying 不为nil时在转发并回复每个请求期间持有读锁
+func forwardChannelRequests(dst ssh.Channel, reqs <-chan *ssh.Request, replying *sync.RWMutex) {
+	for req := range reqs {
+		if replying != nil {
+			replying.RLock()
+		}
+		ok, err := dst.SendRequest(req.Type, req.WantReply, req.Payload)
+		if req.WantReply {
+			req.Reply(ok && err == nil, nil)
+		}
+		if replying != nil {
+			replying.RUnlock()
+		}
+	}
+}
+
+// rejectChannels 拒绝管理连接上的所有通道
+func rejectChannels(chans <-chan ssh.NewChannel) {
+	for nc := range chans {
+		nc.Reject(ssh.Prohibited, "control connection")
+	}
+}
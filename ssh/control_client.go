@@ -0,0 +1,142 @@
+package ssh
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+
+	"mysshw/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// controlStartTimeout 等待后台进程启动的最长时间
+const controlStartTimeout = 3 * time.Second
+
+// controlEnabled 返回节点是否可以通过后台进程复用连接
+// 远程端口转发和 agent 转发需要节点主动打开通道，无法复用，这类节点直接连接
+func (c *defaultClient) controlEnabled() bool {
+	cfg := config.CFG
+	if cfg == nil || !cfg.Control.Enable {
+		return false
+	}
+	if len(c.node.RemoteForwards) > 0 || c.node.ForwardAgent {
+		return false
+	}
+	// 后台进程按名称在配置中查找节点
+	return cfg.FindNode(c.node.Name) != nil
+}
+
+// dialControl 通过控制套接字获得到节点的复用连接，后台进程未运行时自动启动
+// 后台进程无法以非交互方式连接节点(如需要输入密码)时返回错误，调用方改为直接连接
+func dialControl(node *config.SSHNode) (*ssh.Client, error) {
+	conn, err := controlConn(true)
+	if err != nil {
+		return nil, err
+	}
+	return newControlClient(conn, node.Name)
+}
+
+// controlConn 连接控制套接字，start 为true时在后台进程未运行时启动它
+func controlConn(start bool) (net.Conn, error) {
+	socket, err := expandHomeDir(config.CFG.ControlSocket())
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial("unix", socket)
+	if err == nil || !start {
+		return conn, err
+	}
+
+	if err := startControlDaemon(); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(controlStartTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		if conn, err = net.Dial("unix", socket); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, fmt.Errorf("control daemon did not start: %w", err)
+}
+
+// newControlClient 在控制套接字连接上建立 SSH 连接
+func newControlClient(conn net.Conn, user string) (*ssh.Client, error) {
+	clientConfig := &ssh.ClientConfig{
+		User: user,
+		// 控制套接字只有属主可以访问，后台进程每次启动使用临时主机密钥
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         time.Second * 10,
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, "mysshw-control", clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// startControlDaemon 以后台进程方式启动 mysshw control daemon
+func startControlDaemon() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "control", "daemon", "--cfg", config.CFG_PATH)
+	cmd.SysProcAttr = daemonSysProcAttr()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start control daemon: %w", err)
+	}
+	return cmd.Process.Release()
+}
+
+// ControlList 返回后台进程中的复用连接，后台进程未运行时返回空列表
+func ControlList() ([]ControlMasterInfo, error) {
+	client, err := controlAdmin()
+	if err != nil || client == nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ok, payload, err := client.SendRequest(controlListRequest, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("control daemon refused the request")
+	}
+	var infos []ControlMasterInfo
+	if err := json.Unmarshal(payload, &infos); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// ControlStop 关闭节点的复用连接，node 为空时关闭全部连接并停止后台进程
+// 返回后台进程是否在运行以及是否找到该节点的复用连接
+func ControlStop(node string) (running, found bool, err error) {
+	client, err := controlAdmin()
+	if err != nil || client == nil {
+		return false, false, err
+	}
+	defer client.Close()
+
+	ok, _, err := client.SendRequest(controlStopRequest, true, []byte(node))
+	if err != nil {
+		return true, false, err
+	}
+	return true, ok, nil
+}
+
+// controlAdmin 建立管理连接，后台进程未运行时返回nil
+func controlAdmin() (*ssh.Client, error) {
+	conn, err := controlConn(false)
+	if err != nil {
+		return nil, nil
+	}
+	return newControlClient(conn, controlUser)
+}
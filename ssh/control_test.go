@@ -0,0 +1,115 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestConnPair 在内存管道上建立一对 SSH 客户端与服务端连接
+func newTestConnPair(t *testing.T) (*ssh.Client, <-chan ssh.NewChannel) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(signer)
+
+	// net.Pipe 是同步的，双方同时发送版本号会互相阻塞，这里使用本地 TCP 连接
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	type serverSide struct {
+		chans <-chan ssh.NewChannel
+		err   error
+	}
+	done := make(chan serverSide, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			done <- serverSide{nil, err}
+			return
+		}
+		_, chans, reqs, err := ssh.NewServerConn(c, serverConfig)
+		if err == nil {
+			go ssh.DiscardRequests(reqs)
+		}
+		done <- serverSide{chans, err}
+	}()
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	conn, chans, reqs, err := ssh.NewClientConn(c, "test", &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	assert.NoError(t, err)
+	server := <-done
+	assert.NoError(t, server.err)
+	client := ssh.NewClient(conn, chans, reqs)
+	t.Cleanup(func() { client.Close() })
+	return client, server.chans
+}
+
+func TestRelayChannel(t *testing.T) {
+	// 上游: 执行命令后输出 stdout/stderr 并返回退出码 3
+	upstream, upstreamChans := newTestConnPair(t)
+	go func() {
+		for nc := range upstreamChans {
+			if nc.ChannelType() != "session" {
+				nc.Reject(ssh.UnknownChannelType, "unsupported")
+				continue
+			}
+			ch, reqs, err := nc.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				for req := range reqs {
+					req.Reply(req.Type == "exec", nil)
+					if req.Type != "exec" {
+						continue
+					}
+					ch.Write([]byte("out\n"))
+					ch.Stderr().Write([]byte("err\n"))
+					ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{3}))
+					ch.Close()
+				}
+			}()
+		}
+	}()
+
+	// 本地: 所有通道转发到上游，与后台进程的处理方式相同
+	local, localChans := newTestConnPair(t)
+	go func() {
+		for nc := range localChans {
+			go relayChannel(upstream, nc)
+		}
+	}()
+
+	session, err := local.NewSession()
+	assert.NoError(t, err)
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	err = session.Run("uptime")
+
+	var exitErr *ssh.ExitError
+	if assert.ErrorAs(t, err, &exitErr) {
+		assert.Equal(t, 3, exitErr.ExitStatus())
+	}
+	assert.Equal(t, "out\n", stdout.String())
+	assert.Equal(t, "err\n", stderr.String())
+
+	// 上游拒绝的通道原样拒绝给本地
+	_, _, err = local.OpenChannel("unknown@test", nil)
+	var openErr *ssh.OpenChannelError
+	if assert.ErrorAs(t, err, &openErr) {
+		assert.Equal(t, ssh.UnknownChannelType, openErr.Reason)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package ssh
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// daemonSysProcAttr 后台进程脱离当前终端会话，终端关闭后继续运行
+func daemonSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// listenControlSocket 在只有属主可访问的临时目录中监听，设置 0600 权限后再移动到 socket，
+// 其他用户在权限设置之前无法连接
+func listenControlSocket(socket string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(socket), ".control-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "sock")
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	// 套接字移动后由 Serve 删除
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, socket); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
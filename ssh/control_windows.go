@@ -0,0 +1,21 @@
+//go:build windows
+
+package ssh
+
+import (
+	"net"
+	"syscall"
+)
+
+// detachedProcess Windows 的 DETACHED_PROCESS 标志，后台进程不使用当前控制台
+const detachedProcess = 0x00000008
+
+// daemonSysProcAttr 后台进程脱离当前控制台，控制台关闭后继续运行
+func daemonSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess, HideWindow: true}
+}
+
+// listenControlSocket 监听控制套接字，Windows 上文件权限位不起作用，访问由用户目录的 ACL 限制
+func listenControlSocket(socket string) (net.Listener, error) {
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,90 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Exec 建立SSH连接并执行远程命令，命令输出实时写入 stdout/stderr
+// 返回远程命令的退出码；连接、认证等失败时返回错误
+func (c *defaultClient) Exec(ctx context.Context, command string, stdout, stderr io.Writer) (int, error) {
+	if c == nil {
+		return -1, fmt.Errorf("invalid SSH node configuration")
+	}
+	client, err := c.connectContext(ctx)
+	if err != nil {
+		return -1, err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return -1, err
+	}
+	defer session.Close()
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	kctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	dead := c.startKeepalive(kctx, client)
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(command)
+	}()
+
+	select {
+	case <-ctx.Done():
+		// 取消时关闭连接，远程进程随会话结束
+		// 等待 session.Run 返回，之后不再有输出写入 stdout/stderr
+		session.Signal(ssh.SIGTERM)
+		client.Close()
+		<-done
+		return -1, ctx.Err()
+	case err = <-done:
+	}
+	select {
+	case <-dead:
+		return -1, fmt.Errorf("connection to %s timed out", c.node.Host)
+	default:
+	}
+
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	return -1, err
+}
+
+// connectContext 同 connect，ctx 结束时不再等待握手与认证完成
+func (c *defaultClient) connectContext(ctx context.Context) (*ssh.Client, error) {
+	type result struct {
+		client *ssh.Client
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		client, err := c.connect()
+		done <- result{client, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		// 连接稍后建立成功时立即关闭
+		go func() {
+			if r := <-done; r.client != nil {
+				r.client.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.client, r.err
+	}
+}
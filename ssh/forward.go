@@ -0,0 +1,176 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"mysshw/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Forward 建立SSH连接并只运行节点配置的端口转发(不打开终端)，直到ctx结束或连接断开
+func (c *defaultClient) Forward(ctx context.Context) error {
+	if c == nil {
+		return fmt.Errorf("invalid SSH node configuration")
+	}
+	locals, err := config.ParseForwards(c.node.Forwards)
+	if err != nil {
+		return err
+	}
+	remotes, err := config.ParseForwards(c.node.RemoteForwards)
+	if err != nil {
+		return err
+	}
+	if len(locals) == 0 && len(remotes) == 0 {
+		return fmt.Errorf("no forwards configured for node '%s'", c.node.Name)
+	}
+
+	client, err := c.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	fmt.Printf(SSHConnectInfoStr, c.node.SetPort(), c.node.SetUser(), c.node.Host, string(client.ServerVersion()))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, f := range locals {
+		if err := startLocalForward(ctx, client, f); err != nil {
+			return err
+		}
+	}
+	for _, f := range remotes {
+		if err := startRemoteForward(ctx, client, f); err != nil {
+			return err
+		}
+	}
+
+	// 等待退出信号或连接断开
+	dead := c.startKeepalive(ctx, client)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Wait()
+	}()
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-done:
+		select {
+		case <-dead:
+			return fmt.Errorf("connection to %s timed out", c.node.Host)
+		default:
+		}
+		return fmt.Errorf("connection to %s closed: %v", c.node.Host, err)
+	}
+}
+
+// startForwards 启动节点配置的端口转发，失败的规则只给出警告，不影响终端会话
+func (c *defaultClient) startForwards(ctx context.Context, client *ssh.Client) {
+	locals, err := config.ParseForwards(c.node.Forwards)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ForwardWarnStr, err)
+		return
+	}
+	remotes, err := config.ParseForwards(c.node.RemoteForwards)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ForwardWarnStr, err)
+		return
+	}
+	for _, f := range locals {
+		if err := startLocalForward(ctx, client, f); err != nil {
+			fmt.Fprintf(os.Stderr, ForwardWarnStr, err)
+		}
+	}
+	for _, f := range remotes {
+		if err := startRemoteForward(ctx, client, f); err != nil {
+			fmt.Fprintf(os.Stderr, ForwardWarnStr, err)
+		}
+	}
+}
+
+// startLocalForward 在本地监听 f.Listen，每个连接都通过 client 转发到 f.Target
+// ctx 结束时停止监听
+func startLocalForward(ctx context.Context, client *ssh.Client, f config.Forward) error {
+	ln, err := net.Listen("tcp", f.Listen)
+	if err != nil {
+		return fmt.Errorf("local forward %s: %w", f, err)
+	}
+	fmt.Printf(ForwardLocalInfoStr, f.Listen, f.Target)
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				remote, err := client.Dial("tcp", f.Target)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, ForwardDialErrStr, f.Target, err)
+					conn.Close()
+					return
+				}
+				relay(conn, remote)
+			}()
+		}
+	}()
+	return nil
+}
+
+// startRemoteForward 请求服务器监听 f.Listen，每个远程连接都转发到本地的 f.Target
+// ctx 结束时取消远程监听
+func startRemoteForward(ctx context.Context, client *ssh.Client, f config.Forward) error {
+	ln, err := client.Listen("tcp", f.Listen)
+	if err != nil {
+		return fmt.Errorf("remote forward %s: server refused to listen on %s "+
+			"(port in use, or AllowTcpForwarding/GatewayPorts disabled in sshd_config): %w", f, f.Listen, err)
+	}
+	fmt.Printf(ForwardRemoteInfoStr, ln.Addr(), f.Target)
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				local, err := net.Dial("tcp", f.Target)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, ForwardDialErrStr, f.Target, err)
+					conn.Close()
+					return
+				}
+				relay(conn, local)
+			}()
+		}
+	}()
+	return nil
+}
+
+// relay 在两个连接之间双向转发数据，任一方向结束后关闭两端
+func relay(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(a, b)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(b, a)
+		done <- struct{}{}
+	}()
+	<-done
+	a.Close()
+	b.Close()
+}
@@ -0,0 +1,91 @@
+package ssh
+
+import (
+	"strings"
+	"unicode"
+)
+
+// 模糊匹配的评分
+const (
+	fuzzyMatchScore       = 16 // 每个匹配的字符
+	fuzzyConsecutiveBonus = 24 // 与上一个匹配字符相邻
+	fuzzyBoundaryBonus    = 20 // 位于单词开头(开头或 - _ . / @ 空格之后)
+	fuzzyFirstCharBonus   = 12 // 匹配从文本开头开始
+	fuzzyGapPenalty       = 2  // 两个匹配字符之间每跳过一个字符
+)
+
+// FuzzyMatch 判断 pattern 的字符是否按顺序出现在 text 中，不区分大小写
+// 返回匹配的分数(越高越好)与匹配字符在 text 中的位置(按 rune 计)
+// pattern 为空时匹配任何文本，分数为0
+func FuzzyMatch(pattern, text string) (score int, positions []int, ok bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+
+	// 从第一个字符的每个出现位置开始贪心匹配，取分数最高的一次
+	for start := range t {
+		if t[start] != p[0] {
+			continue
+		}
+		s, pos, matched := fuzzyMatchFrom(p, t, start)
+		if matched && (!ok || s > score) {
+			score, positions, ok = s, pos, true
+		}
+	}
+	return score, positions, ok
+}
+
+// fuzzyMatchFrom 从 start 开始依次匹配 pattern 的字符，遇到单词开头或相邻位置时优先使用
+func fuzzyMatchFrom(p, t []rune, start int) (int, []int, bool) {
+	positions := make([]int, 0, len(p))
+	score := 0
+	i := start
+	for _, c := range p {
+		found := -1
+		for j := i; j < len(t); j++ {
+			if t[j] != c {
+				continue
+			}
+			if found < 0 {
+				found = j
+			}
+			// 紧邻上一个匹配或位于单词开头的位置更好，但不跳过相邻的匹配
+			if j == i || fuzzyBoundary(t, j) {
+				found = j
+				break
+			}
+		}
+		if found < 0 {
+			return 0, nil, false
+		}
+
+		score += fuzzyMatchScore
+		if len(positions) > 0 {
+			prev := positions[len(positions)-1]
+			if found == prev+1 {
+				score += fuzzyConsecutiveBonus
+			} else {
+				score -= (found - prev - 1) * fuzzyGapPenalty
+			}
+		} else if found == 0 {
+			score += fuzzyFirstCharBonus
+		}
+		if fuzzyBoundary(t, found) {
+			score += fuzzyBoundaryBonus
+		}
+		positions = append(positions, found)
+		i = found + 1
+	}
+	return score, positions, true
+}
+
+// fuzzyBoundary 判断 t[i] 是否位于单词开头
+func fuzzyBoundary(t []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := t[i-1]
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
@@ -0,0 +1,31 @@
+package ssh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	score, positions, ok := FuzzyMatch("pdb", "prod-db")
+	assert.True(t, ok)
+	assert.Equal(t, []int{0, 5, 6}, positions)
+	assert.Positive(t, score)
+
+	// 不区分大小写，按 rune 计算位置
+	_, positions, ok = FuzzyMatch("DB", "生产-db")
+	assert.True(t, ok)
+	assert.Equal(t, []int{3, 4}, positions)
+
+	_, _, ok = FuzzyMatch("bd", "prod-db")
+	assert.False(t, ok)
+	_, _, ok = FuzzyMatch("", "anything")
+	assert.True(t, ok)
+
+	// 连续匹配与单词开头的分数更高
+	prefix, _, _ := FuzzyMatch("web", "web01")
+	scattered, _, _ := FuzzyMatch("web", "w-e-b")
+	inner, _, _ := FuzzyMatch("web", "cobweb")
+	assert.Greater(t, prefix, inner)
+	assert.Greater(t, prefix, scattered)
+}
@@ -0,0 +1,194 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"mysshw/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultIdentities 未配置 keypath 时依次尝试的私钥文件(~/.ssh 下)，与 OpenSSH 的默认身份文件一致
+var defaultIdentities = []string{"id_ed25519", "id_ecdsa", "id_rsa", "id_dsa"}
+
+// passphraseAttempts 输入私钥密码的最多次数
+const passphraseAttempts = 3
+
+// loadIdentities 加载节点的私钥与对应的用户证书，证书排在私钥之前
+// 未配置 keypath 时尝试默认身份文件，不存在的默认文件直接跳过
+// 加密私钥没有配置 passphrase 时，在服务器接受该公钥后才提示输入密码；batch 为true时跳过这类私钥
+func loadIdentities(node *config.SSHNode, batch bool) []ssh.Signer {
+	paths, explicit := node.KeyPath, true
+	if len(paths) == 0 {
+		explicit = false
+		for _, name := range defaultIdentities {
+			paths = append(paths, filepath.Join("~", ".ssh", name))
+		}
+	}
+
+	var explicitCert *ssh.Certificate
+	if node.CertFile != "" {
+		cert, err := loadUserCert(node.CertFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, SSHCertWarnStr, err)
+		}
+		explicitCert = cert
+	}
+
+	var signers []ssh.Signer
+	certMatched := false
+	for _, p := range paths {
+		keyPath, err := expandHomeDir(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "路径解析错误: %v\n", err)
+			continue
+		}
+		signer, err := loadPrivateKey(keyPath, node.Passphrase, batch)
+		if err != nil {
+			if explicit || !errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			continue
+		}
+		if signer == nil {
+			continue
+		}
+
+		// 证书优先于私钥本身，证书无效时仍然尝试私钥
+		cert := explicitCert
+		if cert == nil || !sameKey(cert.Key, signer.PublicKey()) {
+			cert, err = loadUserCert(keyPath + "-cert.pub")
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, SSHCertWarnStr, err)
+			}
+		}
+		if cert != nil {
+			if !sameKey(cert.Key, signer.PublicKey()) {
+				fmt.Fprintf(os.Stderr, SSHCertWarnStr, fmt.Errorf("certificate does not match private key %s", keyPath))
+			} else if certSigner, err := ssh.NewCertSigner(cert, signer); err != nil {
+				fmt.Fprintf(os.Stderr, SSHCertWarnStr, err)
+			} else {
+				certMatched = certMatched || cert == explicitCert
+				signers = append(signers, certSigner)
+			}
+		}
+		signers = append(signers, signer)
+	}
+	if explicitCert != nil && !certMatched {
+		fmt.Fprintf(os.Stderr, SSHCertWarnStr, fmt.Errorf("%s does not match any private key", node.CertFile))
+	}
+	return signers
+}
+
+// loadPrivateKey 读取私钥，加密私钥优先使用 passphrase 解密
+// 没有 passphrase 时返回在首次签名时提示输入密码的签名器，batch 为true时返回 nil, nil
+func loadPrivateKey(keyPath, passphrase string, batch bool) (ssh.Signer, error) {
+	pemBytes, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(pemBytes)
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keyPath, err)
+		}
+		return signer, nil
+	}
+
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keyPath, err)
+		}
+		return signer, nil
+	}
+	if batch {
+		return nil, nil
+	}
+
+	s := &lazySigner{path: keyPath, pem: pemBytes, pub: missing.PublicKey}
+	if s.pub == nil {
+		// 旧的 PEM 格式加密私钥不包含明文公钥，从 .pub 文件读取
+		if data, err := os.ReadFile(keyPath + ".pub"); err == nil {
+			s.pub, _, _, _, _ = ssh.ParseAuthorizedKey(data)
+		}
+	}
+	if s.pub == nil {
+		// 无法得到公钥时立即解密
+		s.once.Do(s.unlock)
+		if s.err != nil {
+			return nil, s.err
+		}
+		return s.signer, nil
+	}
+	return s, nil
+}
+
+// lazySigner 加密私钥的签名器，第一次签名时才提示输入密码
+// 客户端先询问服务器是否接受公钥，只有被接受的私钥才需要解密
+type lazySigner struct {
+	path   string
+	pem    []byte
+	pub    ssh.PublicKey
+	once   sync.Once
+	signer ssh.AlgorithmSigner
+	err    error
+}
+
+// PublicKey 实现 ssh.Signer
+func (s *lazySigner) PublicKey() ssh.PublicKey {
+	return s.pub
+}
+
+// Sign 实现 ssh.Signer
+func (s *lazySigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	return s.SignWithAlgorithm(rand, data, "")
+}
+
+// SignWithAlgorithm 实现 ssh.AlgorithmSigner，RSA 密钥需要它才能使用 rsa-sha2-256/512 签名
+func (s *lazySigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	s.once.Do(s.unlock)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.signer.SignWithAlgorithm(rand, data, algorithm)
+}
+
+// unlock 提示输入密码并解密私钥，密码错误时最多重试 passphraseAttempts 次
+func (s *lazySigner) unlock() {
+	for attempt := 1; attempt <= passphraseAttempts; attempt++ {
+		passphrase, err := readPassword(fmt.Sprintf(SSHKeyPassphrasePromptStr, s.path))
+		if err != nil {
+			s.err = err
+			return
+		}
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(s.pem, []byte(passphrase))
+		if err == nil {
+			algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+			if !ok {
+				s.err = fmt.Errorf("%s: unsupported key type %s", s.path, signer.PublicKey().Type())
+				return
+			}
+			s.signer, s.err = algorithmSigner, nil
+			return
+		}
+		s.err = fmt.Errorf("%s: %w", s.path, err)
+		if !errors.Is(err, x509.IncorrectPasswordError) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, SSHKeyBadPassphraseStr)
+	}
+}
+
+// sameKey 比较两个公钥是否相同
+func sameKey(a, b ssh.PublicKey) bool {
+	return bytes.Equal(a.Marshal(), b.Marshal())
+}
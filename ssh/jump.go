@@ -0,0 +1,99 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"mysshw/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DialVia 经过跳板链连接目标地址 addr
+// jumps 为跳板节点名称或别名，在 config.CFG 中查找；每一跳使用该节点自身的认证信息，
+// 并通过上一跳的 ssh.Client 建立连接。jumps 为空时直接连接
+func DialVia(jumps []string, addr string, clientConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	return dialVia(jumps, addr, clientConfig, false)
+}
+
+// dialVia 同 DialVia，batch 为true时跳板节点不做任何终端交互(密码、主机密钥确认)，也不打印跳板信息
+func dialVia(jumps []string, addr string, clientConfig *ssh.ClientConfig, batch bool) (*ssh.Client, error) {
+	if len(jumps) == 0 {
+		return ssh.Dial("tcp", addr, clientConfig)
+	}
+	prev, closeAll, err := dialJumps(jumps, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dialHop(prev, addr, clientConfig)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	// 目标连接关闭后依次关闭各跳板连接
+	go func() {
+		client.Wait()
+		closeAll()
+	}()
+	return client, nil
+}
+
+// dialJumps 依次连接跳板链，返回最后一跳的连接与关闭全部跳板连接的函数
+func dialJumps(jumps []string, batch bool) (*ssh.Client, func(), error) {
+	if config.CFG == nil {
+		return nil, nil, fmt.Errorf("jump hosts require a loaded configuration")
+	}
+	hops, err := config.CFG.ResolveJumps(jumps)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var opened []*ssh.Client
+	closeAll := func() {
+		for i := len(opened) - 1; i >= 0; i-- {
+			opened[i].Close()
+		}
+	}
+
+	var prev *ssh.Client
+	for _, hop := range hops {
+		hopAddr := net.JoinHostPort(hop.Host, strconv.Itoa(hop.SetPort()))
+		if !batch {
+			fmt.Fprintf(os.Stderr, SSHJumpInfoStr, hop.Name, hop.SetUser(), hopAddr)
+		}
+		hopClient := genSSHConfig(hop, batch)
+		if hopClient == nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("jump host '%s': failed to build client config", hop.Name)
+		}
+		c, err := dialHop(prev, hopAddr, hopClient.clientConfig)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("jump host '%s': %w", hop.Name, err)
+		}
+		opened = append(opened, c)
+		prev = c
+	}
+	return prev, closeAll, nil
+}
+
+// dialHop 通过 via 建立到 addr 的SSH连接，via 为nil时直接拨号
+func dialHop(via *ssh.Client, addr string, clientConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	if via == nil {
+		return ssh.Dial("tcp", addr, clientConfig)
+	}
+	conn, err := via.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
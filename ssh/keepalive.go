@@ -0,0 +1,66 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"mysshw/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startKeepalive 按节点的保活配置(keepalive_interval/keepalive_count_max)检测连接，直到ctx结束
+// 连续 keepalive_count_max 次无响应时打印提示并关闭连接，返回的通道随之关闭
+func (c *defaultClient) startKeepalive(ctx context.Context, client *ssh.Client) <-chan struct{} {
+	dead := make(chan struct{})
+	interval, countMax := config.CFG.Keepalive(c.node)
+	go keepalive(ctx, client, interval, countMax, func() {
+		fmt.Fprintf(os.Stderr, SSHKeepaliveTimeoutStr, c.node.Host, countMax, interval)
+		close(dead)
+		client.Close()
+	})
+	return dead
+}
+
+// keepalive 定时发送 keepalive@openssh.com 请求，直到ctx结束，interval 为0时不发送
+// 连续 countMax 次没有在 interval 内收到响应时调用 onDead，与 OpenSSH 的 ServerAliveCountMax 一致
+func keepalive(ctx context.Context, client *ssh.Client, interval time.Duration, countMax int, onDead func()) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// 连接卡死时 SendRequest 不会返回，等待时间不超过一个间隔
+		reply := make(chan error, 1)
+		go func() {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			reply <- err
+		}()
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-reply:
+			if err == nil {
+				missed = 0
+				continue
+			}
+		case <-time.After(interval):
+		}
+
+		missed++
+		if missed >= countMax {
+			onDead()
+			return
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mysshw/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// KnownHostsFile mysshw 自己维护的 known_hosts 文件，TOFU 模式下新主机密钥写入此文件
+var KnownHostsFile = "~/.mysshw/known_hosts"
+
+// hostKeyChecker 基于 known_hosts 文件的主机密钥校验器
+type hostKeyChecker struct {
+	mode      string
+	files     []string              // 读取的 known_hosts 文件列表
+	storeFile string                // TOFU 模式下新主机密钥的写入文件
+	prompt    func(msg string) bool // 首次连接时的确认提示，nil 表示无法交互确认
+}
+
+// NewHostKeyCallback 按校验模式(strict || tofu || off)创建主机密钥校验回调
+// 同时读取 ~/.ssh/known_hosts 与 mysshw 自己的 known_hosts 文件
+func NewHostKeyCallback(mode string) ssh.HostKeyCallback {
+	return hostKeyCallback(mode, confirmPrompt)
+}
+
+// hostKeyCallback 创建主机密钥校验回调，prompt 为nil时(批量执行)TOFU 模式拒绝未知主机
+func hostKeyCallback(mode string, prompt func(msg string) bool) ssh.HostKeyCallback {
+	mode = strings.ToLower(mode)
+	if mode == "" {
+		mode = config.HostKeyTOFU
+	}
+	if mode == config.HostKeyOff {
+		return ssh.InsecureIgnoreHostKey()
+	}
+	return newHostKeyChecker(mode, prompt).check
+}
+
+// newHostKeyChecker 创建主机密钥校验器
+func newHostKeyChecker(mode string, prompt func(msg string) bool) *hostKeyChecker {
+	var files []string
+	if u, err := user.Current(); err == nil {
+		files = append(files, filepath.Join(u.HomeDir, ".ssh", "known_hosts"))
+	}
+	storeFile, err := expandHomeDir(KnownHostsFile)
+	if err != nil {
+		storeFile = KnownHostsFile
+	}
+	files = append(files, storeFile)
+
+	return &hostKeyChecker{
+		mode:      mode,
+		files:     files,
+		storeFile: storeFile,
+		prompt:    prompt,
+	}
+}
+
+// check 实现 ssh.HostKeyCallback
+func (h *hostKeyChecker) check(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	if h.mode == config.HostKeyOff {
+		return nil
+	}
+
+	callback, err := h.callback()
+	if err != nil {
+		return err
+	}
+	// known_hosts 中 @cert-authority 信任的主机证书直接通过，
+	// 其他证书与 OpenSSH 一样按证书中的主机密钥校验
+	if cert, ok := key.(*ssh.Certificate); ok {
+		if callback(hostname, remote, cert) == nil {
+			return nil
+		}
+		key = cert.Key
+	}
+
+	err = callback(hostname, remote, key)
+	if err == nil {
+		return nil
+	}
+
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		// 例如 @revoked 标记的密钥
+		return err
+	}
+
+	fingerprint := ssh.FingerprintSHA256(key)
+	// 只有记录了同类型的密钥时才算密钥变更，记录的都是其他类型时与 OpenSSH 一样按未知主机处理
+	if i := slices.IndexFunc(keyErr.Want, func(k knownhosts.KnownKey) bool { return k.Key.Type() == key.Type() }); i >= 0 {
+		// 主机密钥与记录不一致，可能遭受中间人攻击，无论何种模式都拒绝连接
+		want := keyErr.Want[i]
+		fmt.Fprintf(os.Stderr, HostKeyChangedWarnStr, hostname, key.Type(), fingerprint, want.Filename, want.Line)
+		return fmt.Errorf("host key verification failed: host key for %s has changed", hostname)
+	}
+
+	if h.mode == config.HostKeyStrict {
+		return fmt.Errorf("host key verification failed: %s is not in known_hosts (strict_host_key = strict)", hostname)
+	}
+
+	if h.prompt == nil {
+		return fmt.Errorf("host key verification failed: %s is not in known_hosts, connect to it interactively once to trust it", hostname)
+	}
+	if !h.prompt(fmt.Sprintf(HostKeyUnknownPromptStr, hostname, key.Type(), fingerprint)) {
+		return fmt.Errorf("host key verification failed: %s is not trusted", hostname)
+	}
+
+	return h.store(hostname, key)
+}
+
+// callback 加载 known_hosts 文件并返回 knownhosts 的校验回调
+func (h *hostKeyChecker) callback() (ssh.HostKeyCallback, error) {
+	// 只加载已存在的文件，knownhosts.New 遇到不存在的文件会直接报错
+	var files []string
+	for _, f := range h.files {
+		if _, err := os.Stat(f); err == nil {
+			files = append(files, f)
+		}
+	}
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// knownKeyTypes 返回 known_hosts 中为 hostname 记录的主机密钥类型
+func (h *hostKeyChecker) knownKeyTypes(hostname string) []string {
+	callback, err := h.callback()
+	if err != nil {
+		return nil
+	}
+	var keyErr *knownhosts.KeyError
+	if !errors.As(callback(hostname, &net.TCPAddr{}, lookupKey{}), &keyErr) {
+		return nil
+	}
+	var types []string
+	for _, k := range keyErr.Want {
+		if !slices.Contains(types, k.Key.Type()) {
+			types = append(types, k.Key.Type())
+		}
+	}
+	return types
+}
+
+// lookupKey 不与任何密钥相同的公钥，用于查询 known_hosts 中记录的密钥
+type lookupKey struct{}
+
+func (lookupKey) Type() string                        { return "" }
+func (lookupKey) Marshal() []byte                     { return nil }
+func (lookupKey) Verify([]byte, *ssh.Signature) error { return errors.New("lookup key cannot verify") }
+
+// preferKnownHostKeys 与 OpenSSH 一样把 known_hosts 中已记录类型的主机密钥算法排在前面，
+// 服务器有多种主机密钥时优先提供已信任的那一个
+func preferKnownHostKeys(mode, hostname string, algorithms []string) []string {
+	mode = strings.ToLower(mode)
+	if mode == config.HostKeyOff {
+		return algorithms
+	}
+	types := newHostKeyChecker(mode, nil).knownKeyTypes(hostname)
+	if len(types) == 0 {
+		return algorithms
+	}
+	var known, others []string
+	for _, algo := range algorithms {
+		keyType := algo
+		if algo == ssh.KeyAlgoRSASHA256 || algo == ssh.KeyAlgoRSASHA512 {
+			keyType = ssh.KeyAlgoRSA
+		}
+		if slices.Contains(types, keyType) {
+			known = append(known, algo)
+		} else {
+			others = append(others, algo)
+		}
+	}
+	return append(known, others...)
+}
+
+// store 将主机密钥追加到 mysshw 的 known_hosts 文件
+func (h *hostKeyChecker) store(hostname string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(h.storeFile), 0700); err != nil {
+		return fmt.Errorf("failed to create known_hosts directory: %w", err)
+	}
+	f, err := os.OpenFile(h.storeFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open known_hosts file: %w", err)
+	}
+	defer f.Close()
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		return fmt.Errorf("failed to write known_hosts file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, HostKeyAddedStr, hostname, h.storeFile)
+	return nil
+}
+
+// confirmPrompt 在终端中提示用户输入 yes/no
+func confirmPrompt(msg string) bool {
+	fmt.Fprint(os.Stderr, msg)
+	var answer string
+	fmt.Scanln(&answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "yes" || answer == "y"
+}
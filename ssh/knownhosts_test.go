@@ -0,0 +1,102 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mysshw/config"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestHostKey 生成测试用的主机公钥
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	key, err := ssh.NewPublicKey(pub)
+	assert.NoError(t, err)
+	return key
+}
+
+func TestHostKeyChecker(t *testing.T) {
+	dir := t.TempDir()
+	storeFile := filepath.Join(dir, "mysshw", "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 22}
+	hostname := "example.com:22"
+	key := newTestHostKey(t)
+
+	newChecker := func(mode string, prompt func(string) bool) *hostKeyChecker {
+		return &hostKeyChecker{
+			mode:      mode,
+			files:     []string{filepath.Join(dir, "missing_known_hosts"), storeFile},
+			storeFile: storeFile,
+			prompt:    prompt,
+		}
+	}
+
+	// strict 模式拒绝未知主机
+	err := newChecker(config.HostKeyStrict, nil).check(hostname, remote, key)
+	assert.Error(t, err)
+
+	// tofu 模式用户拒绝时不写入
+	err = newChecker(config.HostKeyTOFU, func(string) bool { return false }).check(hostname, remote, key)
+	assert.Error(t, err)
+	_, statErr := os.Stat(storeFile)
+	assert.True(t, os.IsNotExist(statErr), "拒绝后不应生成 known_hosts 文件")
+
+	// tofu 模式用户确认后写入
+	err = newChecker(config.HostKeyTOFU, func(string) bool { return true }).check(hostname, remote, key)
+	assert.NoError(t, err)
+
+	// 已记录的主机在 strict 模式下也能通过，且不再提示
+	err = newChecker(config.HostKeyStrict, nil).check(hostname, remote, key)
+	assert.NoError(t, err)
+
+	// 主机密钥变更时无论是否确认都拒绝
+	err = newChecker(config.HostKeyTOFU, func(string) bool { return true }).check(hostname, remote, newTestHostKey(t))
+	assert.ErrorContains(t, err, "has changed")
+
+	// 只记录了 ed25519 密钥时，服务器提供的 RSA 密钥按未知主机处理而不是密钥变更
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	rsaPub, err := ssh.NewPublicKey(&rsaKey.PublicKey)
+	assert.NoError(t, err)
+	err = newChecker(config.HostKeyStrict, nil).check(hostname, remote, rsaPub)
+	assert.ErrorContains(t, err, "is not in known_hosts")
+	prompted := false
+	err = newChecker(config.HostKeyTOFU, func(string) bool { prompted = true; return true }).check(hostname, remote, rsaPub)
+	assert.NoError(t, err)
+	assert.True(t, prompted)
+	assert.Equal(t, []string{ssh.KeyAlgoED25519, ssh.KeyAlgoRSA}, newChecker(config.HostKeyStrict, nil).knownKeyTypes(hostname))
+	assert.Empty(t, newChecker(config.HostKeyStrict, nil).knownKeyTypes("other.example.com:22"))
+
+	// 已记录类型的主机密钥算法排在前面
+	defer func(f string) { KnownHostsFile = f }(KnownHostsFile)
+	KnownHostsFile = storeFile
+	algorithms := []string{ssh.KeyAlgoECDSA256, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoED25519}
+	assert.Equal(t, []string{ssh.KeyAlgoRSASHA256, ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256},
+		preferKnownHostKeys(config.HostKeyTOFU, hostname, algorithms))
+	assert.Equal(t, algorithms, preferKnownHostKeys(config.HostKeyOff, hostname, algorithms))
+
+	// 没有 CA 信任的主机证书按其中的主机密钥校验: 未知时提示，记录的是主机密钥本身
+	certHost := "cert.example.com:22"
+	hostSigner := newTestSigner(t)
+	cert := signTestCert(t, newTestSigner(t), hostSigner.PublicKey(), ssh.HostCert, []string{"cert.example.com"}, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	err = newChecker(config.HostKeyStrict, nil).check(certHost, remote, cert)
+	assert.ErrorContains(t, err, "is not in known_hosts")
+	err = newChecker(config.HostKeyTOFU, func(string) bool { return true }).check(certHost, remote, cert)
+	assert.NoError(t, err)
+	assert.NoError(t, newChecker(config.HostKeyStrict, nil).check(certHost, remote, hostSigner.PublicKey()))
+	assert.NoError(t, newChecker(config.HostKeyStrict, nil).check(certHost, remote, cert))
+
+	// off 模式不校验
+	err = newChecker(config.HostKeyOff, nil).check(hostname, remote, newTestHostKey(t))
+	assert.NoError(t, err)
+}
@@ -1,13 +1,60 @@
 package ssh
 
 const (
-	MsgSelectNodeGroup     = "Select SSH Node Groups.(选择主机组)"
-	MsgSelectNode          = "Select SSH Node.(选择主机)"
-	MsgSelectDesc          = "Use arrow keys to navigate, press Enter to select."
-	MsgPrintLnStr          = "Operation cancelled"
-	NodeParentAlias        = "返回上级"
-	NodeParentName         = "-parent-"
-	SSHConnectInfoStr      = "connect server ssh -p %d %s@%s version: %s \n"
-	SSHClientConnectPwdStr = "Contains %s@%s's password:"
-	errFormRunError        = "interrupted"
+	MsgSelectNodeGroup           = "Select SSH Node Groups.(选择主机组)"
+	MsgSelectNode                = "Select SSH Node.(选择主机)"
+	MsgSelectDesc                = "Use arrow keys to navigate, press Enter to select."
+	MsgPrintLnStr                = "Operation cancelled"
+	MsgPickerNoMatch             = "no matching nodes"
+	MsgPickerFlatHelp            = "type to search • ↑/↓ move • enter connect • ctrl+s pin/unpin • tab browse groups • esc clear • ctrl+c quit"
+	MsgPickerGroupsHelp          = "type to search • ↑/↓ move • enter open • ctrl+s pin/unpin • esc back • tab search all nodes • ctrl+c quit"
+	MsgPickerFavorites           = "★ Favorites"
+	MsgPickerRecent              = "Recent"
+	MsgPickerAllNodes            = "All nodes"
+	MsgPickerGroups              = "Groups"
+	MsgPickerPinned              = "pinned %s"
+	MsgPickerUnpinned            = "unpinned %s"
+	MsgStateWarnStr              = "Warning: favorites and recent nodes unavailable: %v\n"
+	NodeParentAlias              = "返回上级"
+	NodeParentName               = "-parent-"
+	SSHConnectInfoStr            = "connect server ssh -p %d %s@%s version: %s \n"
+	SSHClientConnectPwdStr       = "Contains %s@%s's password:"
+	SSHKeyPassphrasePromptStr    = "Enter passphrase for key '%s': "
+	SSHKeyBadPassphraseStr       = "Bad passphrase, try again."
+	errFormRunError              = "interrupted"
+	SSHJumpInfoStr               = "jump via %s (%s@%s)\n"
+	ForwardLocalInfoStr          = "forward local %s -> remote %s\r\n"
+	ForwardRemoteInfoStr         = "forward remote %s -> local %s\r\n"
+	SocksListenInfoStr           = "socks5 proxy listening on %s via %s\r\n"
+	ForwardAgentWarnStr          = "Warning: agent forwarding failed: %v\r\n"
+	ForwardAgentUntrustedWarnStr = "Warning: forwarding your ssh-agent to '%s' whose host key is not verified (strict_host_key = off).\r\n" +
+		"Anyone with root access on that host can use your keys while you are connected.\r\n"
+	ForwardWarnStr    = "Warning: port forwarding failed: %v\r\n"
+	ForwardDialErrStr = "forward: connect to %s failed: %v\r\n"
+	RecordInfoStr     = "recording session to %s\r\n"
+	RecordSavedStr    = "session recorded to %s\r\n"
+	RecordWarnStr     = "Warning: session recording disabled: %v\r\n"
+	SSHCertWarnStr    = "Warning: certificate not used: %v\n"
+
+	SSHConnectionLostStr   = "\nconnection to %s lost\n"
+	SSHKeepaliveTimeoutStr = "\r\nno response from %s to %d keepalive requests (interval %s), closing connection\r\n"
+	SSHReconnectingStr     = "reconnecting to %s… (attempt %d/%d in %s)\n"
+	SSHReconnectedStr      = "reconnected to %s\n"
+	SSHReconnectFailedStr  = "reconnect failed: %v\n"
+	SSHReconnectGiveUpStr  = "giving up reconnecting to %s after %d attempts\n"
+
+	HostKeyUnknownPromptStr = "The authenticity of host '%s' can't be established.(无法确认主机的真实性)\n" +
+		"%s key fingerprint is %s.\n" +
+		"Are you sure you want to continue connecting (yes/no)? "
+	HostKeyAddedStr       = "Warning: Permanently added '%s' to the list of known hosts (%s).\n"
+	HostKeyChangedWarnStr = `@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
+@    WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!     @
+@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@
+IT IS POSSIBLE THAT SOMEONE IS DOING SOMETHING NASTY!(可能存在中间人攻击!)
+Someone could be eavesdropping on you right now (man-in-the-middle attack)!
+The host key for '%s' has changed.
+The %s key fingerprint sent by the remote host is %s.
+Offending key in %s:%d
+Host key verification failed, connection refused.(主机密钥校验失败，已拒绝连接)
+`
 )
@@ -0,0 +1,664 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mysshw/config"
+	"mysshw/state"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// pickerProbeTimeout 选择列表中每个节点的连通性检查时间限制
+	pickerProbeTimeout = 3 * time.Second
+	// pickerProbeInterval 选择列表打开期间重新检查的间隔
+	pickerProbeInterval = 15 * time.Second
+	// pickerProbeParallel 同时检查的节点数
+	pickerProbeParallel = 8
+	// pickerDefaultHeight 未取得终端高度时显示的行数
+	pickerDefaultHeight = 10
+	// pickerRecentCount 最近连接部分显示的节点数
+	pickerRecentCount = 5
+)
+
+var (
+	// 与 huh 默认主题保持一致
+	pickerTheme = huh.ThemeCharm()
+	// 可连接 #02BF87
+	reachableStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#02BF87"))
+	// 不可连接 #ED567A
+	unreachableStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ED567A"))
+	// 灰色样式
+	faintStyle = lipgloss.NewStyle().Faint(true)
+	// 标签样式 #A8CC8C
+	tagStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#A8CC8C"))
+	// 搜索匹配的字符 #F780E2
+	matchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#F780E2")).Bold(true).Underline(true)
+	// 分组标题
+	headerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#585858")).Bold(true)
+)
+
+// pickerMode 选择列表的显示方式
+type pickerMode int
+
+const (
+	// pickerFlat 所有组的节点显示在同一个列表中
+	pickerFlat pickerMode = iota
+	// pickerTree 按多级分组逐级浏览，顶级以下每一级第一项为返回上级
+	pickerTree
+)
+
+// probeMsg 后台检查得到的一个节点的结果
+type probeMsg struct {
+	node   *config.SSHNode
+	result ProbeResult
+}
+
+// pickerRow 列表中的一行: 节点、下一级分组、返回上级或不可选择的标题
+type pickerRow struct {
+	header    string          // 不为空时是标题，如"最近连接"
+	node      *config.SSHNode // 不为nil时是节点
+	group     int             // 节点所在组的下标
+	dir       string          // 不为空时是下一级分组，值为完整路径
+	back      bool            // 返回上级
+	score     int
+	positions []int // 搜索匹配的字符在行文本中的位置
+}
+
+// segment 行文本中使用同一样式的一段
+type segment struct {
+	text  string
+	style lipgloss.Style
+}
+
+// pickerModel 节点选择列表
+// 默认在一个列表中显示所有组的节点并支持模糊搜索，按 tab 切换为按多级分组逐级浏览；
+// 没有搜索内容时顶部显示收藏与最近连接的节点；打开期间后台检查节点的连通性并实时更新状态与延迟
+type pickerModel struct {
+	groups []config.Nodes
+	paths  []string                         // 每个节点组的完整路径
+	status map[*config.SSHNode]*ProbeResult // 尚未检查完成的节点不在其中
+	state  *state.State                     // 收藏与连接历史，读取失败时为nil
+	notice string                           // 显示在帮助上方的提示，如保存收藏失败
+
+	mode   pickerMode
+	dir    string // pickerTree 时所在的分组路径，顶级为空
+	query  string
+	rows   []pickerRow
+	cursor int
+	offset int // 可见窗口的第一行
+	height int
+
+	chosen  *config.SSHNode
+	aborted bool
+	done    bool
+}
+
+// newPickerModel 创建选择列表，st 为nil时不显示收藏与最近连接
+func newPickerModel(cfg *config.Configs, st *state.State) *pickerModel {
+	m := &pickerModel{
+		groups: cfg.Nodes,
+		paths:  make([]string, len(cfg.Nodes)),
+		status: map[*config.SSHNode]*ProbeResult{},
+		state:  st,
+		height: pickerDefaultHeight,
+	}
+	for i := range cfg.Nodes {
+		m.paths[i] = cfg.GroupPath(&cfg.Nodes[i])
+	}
+	m.refresh()
+	return m
+}
+
+// Init 实现 tea.Model
+func (m *pickerModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update 实现 tea.Model
+func (m *pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case probeMsg:
+		r := msg.result
+		m.status[msg.node] = &r
+	case tea.WindowSizeMsg:
+		// 标题、边框与帮助各占一行
+		m.height = max(msg.Height-4, 1)
+		m.scroll()
+	case tea.KeyMsg:
+		return m, m.handleKey(msg)
+	}
+	return m, nil
+}
+
+// handleKey 处理按键: 输入字符即搜索，方向键移动，tab 切换显示方式
+func (m *pickerModel) handleKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.aborted, m.done = true, true
+		return tea.Quit
+	case tea.KeyEnter:
+		return m.enter()
+	case tea.KeyTab:
+		m.dir = ""
+		if m.mode == pickerFlat {
+			m.setMode(pickerTree)
+		} else {
+			m.setMode(pickerFlat)
+		}
+	case tea.KeyEsc:
+		switch {
+		case m.query != "":
+			m.setQuery("")
+		case m.mode == pickerTree && m.dir != "":
+			m.up()
+		}
+	case tea.KeyUp, tea.KeyCtrlP, tea.KeyShiftTab:
+		m.move(-1)
+	case tea.KeyDown, tea.KeyCtrlN:
+		m.move(1)
+	case tea.KeyPgUp:
+		m.move(-m.height)
+	case tea.KeyPgDown:
+		m.move(m.height)
+	case tea.KeyHome:
+		m.cursor = 0
+		m.move(0)
+	case tea.KeyEnd:
+		m.cursor = len(m.rows) - 1
+		m.move(0)
+	case tea.KeyBackspace:
+		if r := []rune(m.query); len(r) > 0 {
+			m.setQuery(string(r[:len(r)-1]))
+		}
+	case tea.KeyCtrlU:
+		m.setQuery("")
+	case tea.KeyCtrlS:
+		m.togglePin()
+	case tea.KeyRunes, tea.KeySpace:
+		m.setQuery(m.query + string(msg.Runes))
+	}
+	return nil
+}
+
+// enter 选中当前行: 连接节点、进入下一级分组或返回上级
+func (m *pickerModel) enter() tea.Cmd {
+	if len(m.rows) == 0 {
+		return nil
+	}
+	row := m.rows[m.cursor]
+	switch {
+	case row.node != nil:
+		m.chosen, m.done = row.node, true
+		return tea.Quit
+	case row.back:
+		m.up()
+	case row.dir != "":
+		m.dir = row.dir
+		m.setMode(pickerTree)
+	}
+	return nil
+}
+
+// up 返回上一级分组，光标停在刚离开的分组上
+func (m *pickerModel) up() {
+	from := m.dir
+	m.dir = config.ParentGroupPath(m.dir)
+	m.setMode(pickerTree)
+	for i, row := range m.rows {
+		if row.dir == from {
+			m.cursor = i
+			m.scroll()
+			break
+		}
+	}
+}
+
+// togglePin 收藏或取消收藏光标所在的节点并立即保存
+func (m *pickerModel) togglePin() {
+	if m.state == nil || len(m.rows) == 0 || m.rows[m.cursor].node == nil {
+		return
+	}
+	node := m.rows[m.cursor].node
+	// 在最新的状态文件上修改，不覆盖其他 mysshw 进程期间记录的历史
+	var pinned bool
+	st, err := state.Update(func(s *state.State) { pinned = s.ToggleFavorite(node.Name) })
+	if err != nil {
+		m.notice = err.Error()
+		return
+	}
+	m.state = st
+	m.notice = fmt.Sprintf(MsgPickerUnpinned, node.Name)
+	if pinned {
+		m.notice = fmt.Sprintf(MsgPickerPinned, node.Name)
+	}
+	cursor := m.cursor
+	m.refresh()
+	m.cursor = min(cursor, max(len(m.rows)-1, 0))
+	m.move(0)
+}
+
+// setMode 切换显示方式并清空搜索
+func (m *pickerModel) setMode(mode pickerMode) {
+	m.mode, m.query = mode, ""
+	m.refresh()
+}
+
+// setQuery 更新搜索内容并重新过滤
+func (m *pickerModel) setQuery(query string) {
+	m.query = query
+	m.refresh()
+}
+
+// move 移动光标并保持光标在可见窗口内，跳过标题行
+func (m *pickerModel) move(delta int) {
+	if len(m.rows) == 0 {
+		m.cursor = 0
+		return
+	}
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	cursor := min(max(m.cursor+delta, 0), len(m.rows)-1)
+	// 先沿移动方向寻找可选择的行，到达边界时反向寻找
+	for _, dir := range []int{step, -step} {
+		for c := cursor; c >= 0 && c < len(m.rows); c += dir {
+			if m.rows[c].header == "" {
+				m.cursor = c
+				m.scroll()
+				return
+			}
+		}
+	}
+}
+
+// scroll 调整可见窗口使光标可见，光标上方紧邻的标题也保持可见
+func (m *pickerModel) scroll() {
+	top := m.cursor
+	if top > 0 && m.rows[top-1].header != "" {
+		top--
+	}
+	if top < m.offset {
+		m.offset = top
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+}
+
+// refresh 按显示方式与搜索内容重新生成列表
+// 搜索内容按空白分为多个词，每个词都要模糊匹配行文本；有搜索内容时按匹配分数排序，返回上级始终在第一行
+func (m *pickerModel) refresh() {
+	var candidates []pickerRow
+	switch m.mode {
+	case pickerFlat:
+		for gi, group := range m.groups {
+			for _, node := range group.SSHNodes {
+				candidates = append(candidates, pickerRow{node: node, group: gi})
+			}
+		}
+	case pickerTree:
+		candidates = m.treeRows()
+	}
+
+	terms := strings.Fields(m.query)
+	m.rows = m.rows[:0]
+	if len(terms) == 0 && m.dir == "" {
+		m.rows = append(m.rows, m.sections()...)
+		if len(m.rows) > 0 {
+			title := MsgPickerAllNodes
+			if m.mode == pickerTree {
+				title = MsgPickerGroups
+			}
+			m.rows = append(m.rows, pickerRow{header: title})
+		}
+	}
+	for _, row := range candidates {
+		if row.back {
+			m.rows = append(m.rows, row)
+			continue
+		}
+		if matchTerms(terms, plainText(m.segments(row)), &row) {
+			m.rows = append(m.rows, row)
+		}
+	}
+	if len(terms) > 0 {
+		sort.SliceStable(m.rows, func(i, j int) bool {
+			if m.rows[i].back || m.rows[j].back {
+				return m.rows[i].back && !m.rows[j].back
+			}
+			return m.rows[i].score > m.rows[j].score
+		})
+	}
+	m.cursor, m.offset = 0, 0
+	m.move(0)
+}
+
+// treeRows 返回当前分组这一级的行: 返回上级、下一级分组、属于当前分组的节点
+func (m *pickerModel) treeRows() []pickerRow {
+	var rows []pickerRow
+	if m.dir != "" {
+		rows = append(rows, pickerRow{back: true})
+	}
+	seen := map[string]bool{}
+	for gi, path := range m.paths {
+		if path == m.dir || !inGroupPath(path, m.dir) {
+			continue
+		}
+		// 下一级分组的路径: 当前路径再加一级
+		rest := config.SplitGroupPath(strings.TrimPrefix(path, m.dir))
+		dir := config.JoinGroupPath(m.dir, rest[0])
+		if !seen[dir] {
+			seen[dir] = true
+			rows = append(rows, pickerRow{dir: dir, group: gi})
+		}
+	}
+	for gi, path := range m.paths {
+		if path != m.dir {
+			continue
+		}
+		for _, node := range m.groups[gi].SSHNodes {
+			rows = append(rows, pickerRow{node: node, group: gi})
+		}
+	}
+	return rows
+}
+
+// inGroupPath 判断路径 path 是否为 dir 或在 dir 之下，dir 为空表示顶级
+func inGroupPath(path, dir string) bool {
+	return dir == "" || path == dir || strings.HasPrefix(path, dir+config.GroupPathSep)
+}
+
+// countNodes 统计分组 dir 及其下级分组中的节点数
+func (m *pickerModel) countNodes(dir string) int {
+	n := 0
+	for gi, path := range m.paths {
+		if inGroupPath(path, dir) {
+			n += len(m.groups[gi].SSHNodes)
+		}
+	}
+	return n
+}
+
+// sections 返回收藏与最近连接部分的行，已不在配置中的节点被忽略
+func (m *pickerModel) sections() []pickerRow {
+	if m.state == nil {
+		return nil
+	}
+	var rows []pickerRow
+	add := func(header string, names []string) {
+		var section []pickerRow
+		for _, name := range names {
+			if row, ok := m.findNodeRow(name); ok {
+				section = append(section, row)
+			}
+		}
+		if len(section) > 0 {
+			rows = append(rows, pickerRow{header: header})
+			rows = append(rows, section...)
+		}
+	}
+	add(MsgPickerFavorites, m.state.Favorites)
+	add(MsgPickerRecent, m.state.Recent(pickerRecentCount))
+	return rows
+}
+
+// findNodeRow 按名称查找节点所在的行
+func (m *pickerModel) findNodeRow(name string) (pickerRow, bool) {
+	for gi, group := range m.groups {
+		for _, node := range group.SSHNodes {
+			if node.Name == name {
+				return pickerRow{node: node, group: gi}, true
+			}
+		}
+	}
+	return pickerRow{}, false
+}
+
+// matchTerms 每个词都匹配 text 时记录分数与匹配位置并返回true
+func matchTerms(terms []string, text string, row *pickerRow) bool {
+	row.score, row.positions = 0, nil
+	for _, term := range terms {
+		score, positions, ok := FuzzyMatch(term, text)
+		if !ok {
+			return false
+		}
+		row.score += score
+		row.positions = append(row.positions, positions...)
+	}
+	return true
+}
+
+// segments 返回一行的文本，搜索在整行文本上进行
+// 节点: 组路径 / 名称 (别名) user@host #标签 备注，在所属分组中浏览时不显示组路径；分组: 名称/ 与其中的节点数
+func (m *pickerModel) segments(row pickerRow) []segment {
+	plain := lipgloss.NewStyle()
+	switch {
+	case row.back:
+		return []segment{{NodeParentName + " " + NodeParentAlias, parentStyle}}
+	case row.dir != "":
+		parts := config.SplitGroupPath(row.dir)
+		return []segment{
+			{parts[len(parts)-1] + config.GroupPathSep, plain},
+			{fmt.Sprintf(" (%d)", m.countNodes(row.dir)), faintStyle},
+		}
+	}
+
+	node := row.node
+	var segs []segment
+	if path := m.paths[row.group]; m.mode == pickerFlat || path != m.dir {
+		segs = append(segs, segment{path + " / ", faintStyle})
+	}
+	segs = append(segs, segment{node.Name, plain})
+	if node.Alias != "" {
+		segs = append(segs, segment{" (" + node.Alias + ")", yellowStyle})
+	}
+	if node.Host != "" {
+		userHost := node.Host
+		if node.User != "" {
+			userHost = node.User + "@" + userHost
+		}
+		segs = append(segs, segment{" " + userHost, blueStyle})
+	}
+	for _, tag := range node.Tags {
+		segs = append(segs, segment{" #" + tag, tagStyle})
+	}
+	if node.Notes != "" {
+		segs = append(segs, segment{" " + node.Notes, faintStyle})
+	}
+	return segs
+}
+
+// plainText 拼接各段的文本
+func plainText(segs []segment) string {
+	var b strings.Builder
+	for _, s := range segs {
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
+
+// renderSegments 渲染各段文本并高亮匹配的字符，dim 为true时整行变暗(高亮仍然保留)
+func renderSegments(segs []segment, positions []int, dim bool) string {
+	matched := map[int]bool{}
+	for _, p := range positions {
+		matched[p] = true
+	}
+	var b strings.Builder
+	i := 0
+	for _, s := range segs {
+		style := s.style
+		if dim {
+			style = faintStyle
+		}
+		// 连续的匹配或不匹配字符一起渲染
+		var run []rune
+		runMatched := false
+		flush := func() {
+			if len(run) == 0 {
+				return
+			}
+			if runMatched {
+				b.WriteString(matchStyle.Render(string(run)))
+			} else {
+				b.WriteString(style.Render(string(run)))
+			}
+			run = run[:0]
+		}
+		for _, r := range s.text {
+			if matched[i] != runMatched {
+				flush()
+				runMatched = matched[i]
+			}
+			run = append(run, r)
+			i++
+		}
+		flush()
+	}
+	return b.String()
+}
+
+// View 实现 tea.Model
+func (m *pickerModel) View() string {
+	if m.done {
+		return ""
+	}
+	theme := pickerTheme.Focused
+	title := MsgSelectNode
+	if m.mode == pickerTree {
+		title = MsgSelectNodeGroup
+		if m.dir != "" {
+			title = MsgSelectNode + " " + faintStyle.Render(m.dir)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(theme.Title.Render(title))
+	b.WriteString(" " + theme.TextInput.Prompt.Render(">") + " " + m.query + "\n")
+	end := min(m.offset+m.height, len(m.rows))
+	for i := m.offset; i < end; i++ {
+		if i == m.cursor {
+			b.WriteString(theme.SelectSelector.String())
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(m.renderRow(m.rows[i]))
+		if i < end-1 {
+			b.WriteString("\n")
+		}
+	}
+	if len(m.rows) == 0 {
+		b.WriteString(faintStyle.Render("  " + MsgPickerNoMatch))
+	}
+	help := MsgPickerFlatHelp
+	if m.mode != pickerFlat {
+		help = MsgPickerGroupsHelp
+	}
+	if m.notice != "" {
+		help = m.notice + " • " + help
+	}
+	return theme.Base.Render(b.String()) + "\n" + faintStyle.Render(help) + "\n"
+}
+
+// renderRow 渲染一行，节点前显示状态点与收藏标记，之后显示延迟；不可连接的节点整行变暗
+func (m *pickerModel) renderRow(row pickerRow) string {
+	if row.header != "" {
+		return headerStyle.Render(row.header)
+	}
+	segs := m.segments(row)
+	if row.node == nil {
+		return renderSegments(segs, row.positions, false)
+	}
+	pin := ""
+	if m.state != nil && m.state.IsFavorite(row.node.Name) {
+		pin = yellowStyle.Render("★ ")
+	}
+	status := m.status[row.node]
+	switch {
+	case status == nil:
+		return faintStyle.Render("○ ") + pin + renderSegments(segs, row.positions, false)
+	case status.Reachable:
+		return reachableStyle.Render("● ") + pin + renderSegments(segs, row.positions, false) +
+			faintStyle.Render(fmt.Sprintf(" %.1fms", status.LatencyMS))
+	default:
+		reason := " unreachable"
+		if status.TimedOut {
+			reason = " timed out"
+		}
+		return unreachableStyle.Render("● ") + pin + renderSegments(segs, row.positions, true) + faintStyle.Render(reason)
+	}
+}
+
+// runPicker 显示节点选择列表，打开期间在后台定期检查节点的 TCP 连通性
+// 返回选中的节点；用户取消时返回 errFormRunError
+func runPicker(cfg *config.Configs, st *state.State) (*config.SSHNode, error) {
+	m := newPickerModel(cfg, st)
+	p := tea.NewProgram(m)
+
+	var nodes []*config.SSHNode
+	for _, group := range cfg.Nodes {
+		nodes = append(nodes, group.SSHNodes...)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		probeLoop(ctx, nodes, p.Send)
+	}()
+
+	_, err := p.Run()
+	cancel()
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if m.aborted {
+		return nil, errors.New(errFormRunError)
+	}
+	return m.chosen, nil
+}
+
+// probeLoop 检查所有节点，之后每隔 pickerProbeInterval 重新检查，直到 ctx 结束
+// 经过跳板的节点在整个列表打开期间复用同一个跳板连接
+func probeLoop(ctx context.Context, nodes []*config.SSHNode, send func(tea.Msg)) {
+	jumps := newJumpPool()
+	defer jumps.Close()
+	for {
+		sem := make(chan struct{}, pickerProbeParallel)
+		var wg sync.WaitGroup
+		for _, node := range nodes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				defer func() { <-sem }()
+				r := probeVia(ctx, node, ProbeTCP, pickerProbeTimeout, jumps)
+				if ctx.Err() == nil {
+					send(probeMsg{node: node, result: r})
+				}
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pickerProbeInterval):
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+
+	"mysshw/config"
+	"mysshw/state"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickerModel(t *testing.T) {
+	web := &config.SSHNode{Name: "web01", Host: "10.0.0.1", User: "root"}
+	db := &config.SSHNode{Name: "db01", Alias: "db", Host: "10.0.0.2", Tags: config.StringList{"mysql"}, Notes: "primary"}
+	cache := &config.SSHNode{Name: "cache01", Host: "10.0.1.1"}
+	groups := []config.Nodes{
+		{Groups: "prod", SSHNodes: []*config.SSHNode{web, db}},
+		{Groups: "staging", SSHNodes: []*config.SSHNode{cache}},
+	}
+	m := newPickerModel(&config.Configs{Nodes: groups}, nil)
+	key := func(k tea.KeyType, runes ...rune) {
+		m.Update(tea.KeyMsg{Type: k, Runes: runes})
+	}
+	nodes := func() []*config.SSHNode {
+		var list []*config.SSHNode
+		for _, row := range m.rows {
+			list = append(list, row.node)
+		}
+		return list
+	}
+
+	// 所有组的节点显示在同一个列表中
+	assert.Equal(t, []*config.SSHNode{web, db, cache}, nodes())
+	assert.Contains(t, m.View(), "prod / db01 (db) 10.0.0.2 #mysql primary")
+
+	// 检查结果到达前显示空心点，之后显示延迟或变暗
+	assert.Contains(t, m.View(), "○")
+	m.Update(probeMsg{node: web, result: ProbeResult{Reachable: true, LatencyMS: 12.3}})
+	m.Update(probeMsg{node: cache, result: ProbeResult{Error: "connection refused"}})
+	assert.Contains(t, m.View(), "12.3ms")
+	assert.Contains(t, m.View(), "unreachable")
+
+	// 搜索标签与备注，多个词都要匹配
+	key(tea.KeyRunes, []rune("mysql")...)
+	assert.Equal(t, []*config.SSHNode{db}, nodes())
+	assert.Equal(t, []int{27, 28, 29, 30, 31}, m.rows[0].positions)
+	key(tea.KeyCtrlU)
+	key(tea.KeyRunes, []rune("stag c01")...)
+	assert.Equal(t, []*config.SSHNode{cache}, nodes())
+	key(tea.KeyRunes, []rune("zz")...)
+	assert.Empty(t, m.rows)
+	assert.Contains(t, m.View(), MsgPickerNoMatch)
+	key(tea.KeyEsc)
+	assert.Len(t, m.rows, 3)
+
+	// tab 切换为按组浏览: 进入组后第一行是返回上级
+	key(tea.KeyTab)
+	assert.Equal(t, pickerTree, m.mode)
+	assert.Len(t, m.rows, 2)
+	key(tea.KeyDown)
+	key(tea.KeyEnter)
+	assert.Equal(t, "staging", m.dir)
+	assert.Equal(t, []*config.SSHNode{nil, cache}, nodes())
+	assert.True(t, m.rows[0].back)
+	key(tea.KeyEnter)
+	assert.Equal(t, "", m.dir)
+	assert.Equal(t, 1, m.cursor)
+	key(tea.KeyEnter)
+	key(tea.KeyEsc)
+	assert.Equal(t, "", m.dir)
+	key(tea.KeyUp)
+	key(tea.KeyEnter)
+	key(tea.KeyRunes, []rune("db")...)
+	assert.Equal(t, []*config.SSHNode{nil, db}, nodes())
+	key(tea.KeyDown)
+	key(tea.KeyEnter)
+	assert.True(t, m.done)
+	assert.Same(t, db, m.chosen)
+	assert.Empty(t, strings.TrimSpace(m.View()))
+}
+
+func TestPickerSections(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	web := &config.SSHNode{Name: "web01", Host: "10.0.0.1"}
+	db := &config.SSHNode{Name: "db01", Host: "10.0.0.2"}
+	cache := &config.SSHNode{Name: "cache01", Host: "10.0.1.1"}
+	groups := []config.Nodes{
+		{Groups: "prod", SSHNodes: []*config.SSHNode{web, db}},
+		{Groups: "staging", SSHNodes: []*config.SSHNode{cache}},
+	}
+	st := &state.State{
+		Favorites: []string{"db01", "removed"},
+		History:   []state.Entry{{Node: "cache01"}, {Node: "web01"}},
+	}
+	assert.NoError(t, st.Save())
+	m := newPickerModel(&config.Configs{Nodes: groups}, st)
+	key := func(k tea.KeyType, runes ...rune) {
+		m.Update(tea.KeyMsg{Type: k, Runes: runes})
+	}
+	headers := func() []string {
+		var list []string
+		for _, row := range m.rows {
+			list = append(list, row.header)
+		}
+		return list
+	}
+
+	// 收藏与最近连接显示在所有节点之前，已删除的节点被忽略，光标跳过标题
+	assert.Equal(t, []string{MsgPickerFavorites, "", MsgPickerRecent, "", "", MsgPickerAllNodes, "", "", ""}, headers())
+	assert.Equal(t, 1, m.cursor)
+	assert.Same(t, db, m.rows[1].node)
+	assert.Same(t, web, m.rows[3].node)
+	assert.Contains(t, stripANSI(m.View()), "★ prod / db01")
+	key(tea.KeyDown)
+	assert.Equal(t, 3, m.cursor)
+	key(tea.KeyUp)
+	key(tea.KeyUp)
+	assert.Equal(t, 1, m.cursor)
+
+	// 搜索时不显示收藏与最近连接
+	key(tea.KeyRunes, []rune("web")...)
+	assert.Equal(t, []string{""}, headers())
+	key(tea.KeyEsc)
+
+	// ctrl+s 收藏光标所在的节点并保存，不覆盖其他进程期间记录的历史
+	assert.NoError(t, state.Record(state.Entry{Node: "db01"}))
+	key(tea.KeyDown)
+	key(tea.KeyCtrlS)
+	assert.Equal(t, []string{"db01", "removed", "web01"}, m.state.Favorites)
+	assert.Contains(t, m.View(), "pinned web01")
+	saved, err := state.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, m.state.Favorites, saved.Favorites)
+	assert.Len(t, saved.History, 3)
+
+	// 按组浏览时同样显示在组之前
+	key(tea.KeyTab)
+	assert.Equal(t, pickerTree, m.mode)
+	assert.Equal(t, MsgPickerGroups, m.rows[len(m.rows)-3].header)
+}
+
+func TestPickerTree(t *testing.T) {
+	web := &config.SSHNode{Name: "web01", Host: "10.0.0.1"}
+	db := &config.SSHNode{Name: "db01", Host: "10.0.0.2"}
+	cache := &config.SSHNode{Name: "cache01", Host: "10.0.1.1"}
+	cfg := &config.Configs{Nodes: []config.Nodes{
+		{Groups: "cn-east/prod", SSHNodes: []*config.SSHNode{web}},
+		{Groups: "db", Parent: "cn-east/prod", SSHNodes: []*config.SSHNode{db}},
+		{Groups: "us-west", SSHNodes: []*config.SSHNode{cache}},
+	}}
+	m := newPickerModel(cfg, nil)
+	m.setMode(pickerTree)
+	key := func(k tea.KeyType) {
+		m.Update(tea.KeyMsg{Type: k})
+	}
+	dirs := func() []string {
+		var list []string
+		for _, row := range m.rows {
+			switch {
+			case row.back:
+				list = append(list, "..")
+			case row.node != nil:
+				list = append(list, row.node.Name)
+			default:
+				list = append(list, row.dir)
+			}
+		}
+		return list
+	}
+
+	// 每一级显示下一级分组与本级的节点，分组后显示其中(含下级)的节点数
+	assert.Equal(t, []string{"cn-east", "us-west"}, dirs())
+	assert.Contains(t, stripANSI(m.View()), "cn-east/ (2)")
+	key(tea.KeyEnter)
+	assert.Equal(t, []string{"..", "cn-east/prod"}, dirs())
+	key(tea.KeyDown)
+	key(tea.KeyEnter)
+	assert.Equal(t, []string{"..", "cn-east/prod/db", "web01"}, dirs())
+	assert.Contains(t, stripANSI(m.View()), "web01 10.0.0.1")
+	key(tea.KeyDown)
+	key(tea.KeyEnter)
+	assert.Equal(t, []string{"..", "db01"}, dirs())
+
+	// 返回上级时光标停在刚离开的分组上，顶级没有返回上级
+	key(tea.KeyEsc)
+	assert.Equal(t, "cn-east/prod", m.dir)
+	assert.Equal(t, 1, m.cursor)
+	key(tea.KeyUp)
+	key(tea.KeyEnter)
+	key(tea.KeyEsc)
+	assert.Equal(t, "", m.dir)
+	assert.Equal(t, 0, m.cursor)
+	key(tea.KeyEsc)
+	assert.Equal(t, "", m.dir)
+
+	// 平铺列表显示完整路径
+	key(tea.KeyTab)
+	assert.Contains(t, stripANSI(m.View()), "cn-east/prod/db / db01")
+}
+
+func TestRenderSegments(t *testing.T) {
+	segs := []segment{{"prod / ", faintStyle}, {"db01", yellowStyle}}
+	out := renderSegments(segs, []int{7, 8}, false)
+	assert.Contains(t, out, "db")
+	assert.Equal(t, "prod / db01", stripANSI(out))
+}
+
+// stripANSI 去掉终端颜色控制序列
+func stripANSI(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\x1b' {
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
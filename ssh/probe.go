@@ -0,0 +1,355 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mysshw/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ProbeLevel 连通性检查的深度
+type ProbeLevel int
+
+const (
+	// ProbeTCP 只检查 TCP 端口是否可以连接
+	ProbeTCP ProbeLevel = iota
+	// ProbeHandshake 完成 SSH 握手并校验主机密钥
+	ProbeHandshake
+	// ProbeAuth 完成握手后使用节点的认证信息登录(不交互)
+	ProbeAuth
+)
+
+// 认证检查结果
+const (
+	ProbeAuthOK     = "ok"
+	ProbeAuthFailed = "failed"
+)
+
+// ProbeResult 单个节点的连通性检查结果
+type ProbeResult struct {
+	Group   string `json:"group"`
+	Node    string `json:"node"`
+	Address string `json:"address"`
+	// Reachable TCP 端口是否可以连接(经过跳板时为跳板上的连接)
+	Reachable bool `json:"reachable"`
+	// Latency 建立 TCP 连接的耗时，LatencyMS 为其毫秒数
+	Latency   time.Duration `json:"-"`
+	LatencyMS float64       `json:"latency_ms"`
+	// Handshake SSH 握手是否完成，只在检查握手或认证时有意义
+	Handshake     bool   `json:"handshake,omitempty"`
+	ServerVersion string `json:"server_version,omitempty"`
+	// Auth 认证结果: ok || failed，不检查认证时为空
+	Auth     string `json:"auth,omitempty"`
+	TimedOut bool   `json:"timed_out,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// OK 返回节点是否通过了 level 要求的全部检查
+func (r ProbeResult) OK(level ProbeLevel) bool {
+	switch {
+	case !r.Reachable || r.TimedOut:
+		return false
+	case level >= ProbeAuth:
+		return r.Auth == ProbeAuthOK
+	case level >= ProbeHandshake:
+		return r.Handshake
+	default:
+		return true
+	}
+}
+
+// Probe 检查节点的连通性，timeout 为整个检查的时间限制
+// 握手与认证使用与 exec --group 相同的非交互配置：不提示输入密码，未知主机密钥直接拒绝
+func Probe(ctx context.Context, node *config.SSHNode, level ProbeLevel, timeout time.Duration) ProbeResult {
+	return probeVia(ctx, node, level, timeout, nil)
+}
+
+// probeVia 同 Probe，jumps 不为nil时经过跳板的节点复用其中的跳板连接
+func probeVia(ctx context.Context, node *config.SSHNode, level ProbeLevel, timeout time.Duration, jumps *jumpPool) ProbeResult {
+	addr := net.JoinHostPort(node.Host, strconv.Itoa(node.SetPort()))
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// 客户端配置在这里生成，超时后仍在运行的检查不再读取 config.CFG
+	var c *defaultClient
+	if level > ProbeTCP {
+		if c = genSSHConfig(node, true); c == nil {
+			return ProbeResult{Node: node.Name, Address: addr, Error: "failed to build client config"}
+		}
+	}
+
+	done := make(chan ProbeResult, 1)
+	go func() {
+		done <- probe(ctx, node, addr, level, c, jumps)
+	}()
+	select {
+	case r := <-done:
+		return r
+	case <-ctx.Done():
+		// 卡在握手中的检查在连接关闭后自行结束
+		return ProbeResult{Node: node.Name, Address: addr, TimedOut: true, Error: "timed out"}
+	}
+}
+
+// probe 依次进行 TCP 连接、握手与认证，c 为握手与认证使用的客户端配置，只检查 TCP 时为nil
+func probe(ctx context.Context, node *config.SSHNode, addr string, level ProbeLevel, c *defaultClient, jumps *jumpPool) ProbeResult {
+	r := ProbeResult{Node: node.Name, Address: addr}
+
+	start := time.Now()
+	conn, closeJumps, err := probeDial(ctx, node, addr, jumps)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	defer closeJumps()
+	defer conn.Close()
+	r.Reachable = true
+	r.Latency = time.Since(start)
+	r.LatencyMS = float64(r.Latency.Microseconds()) / 1000
+	if level == ProbeTCP {
+		return r
+	}
+
+	// 连接在 ctx 结束时关闭，使卡住的握手返回
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	cc := *c.clientConfig
+	if level < ProbeAuth {
+		cc.Auth = nil
+	}
+	// 主机密钥校验通过说明握手已经完成，之后的错误来自认证
+	var verified atomic.Bool
+	hostKeyCallback := cc.HostKeyCallback
+	cc.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := hostKeyCallback(hostname, remote, key)
+		verified.Store(err == nil)
+		return err
+	}
+
+	vc := &versionConn{Conn: conn}
+	sshConn, chans, reqs, err := ssh.NewClientConn(vc, addr, &cc)
+	r.ServerVersion = vc.version()
+	r.Handshake = verified.Load()
+	if err == nil {
+		r.Handshake = true
+		if level >= ProbeAuth {
+			r.Auth = ProbeAuthOK
+		}
+		ssh.NewClient(sshConn, chans, reqs).Close()
+		return r
+	}
+	if ctx.Err() != nil {
+		r.TimedOut = true
+		r.Error = "timed out"
+		return r
+	}
+	if r.Handshake && level < ProbeAuth {
+		// 只检查握手时没有提供认证方式，认证失败是预期的
+		return r
+	}
+	if r.Handshake {
+		r.Auth = ProbeAuthFailed
+	}
+	r.Error = err.Error()
+	return r
+}
+
+// probeDial 建立到节点的 TCP 连接，配置了跳板时经过跳板链连接
+// jumps 不为nil时使用其中缓存的跳板连接，返回的关闭函数不会关闭跳板
+func probeDial(ctx context.Context, node *config.SSHNode, addr string, jumps *jumpPool) (net.Conn, func(), error) {
+	if len(node.Jump) == 0 {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		return conn, func() {}, err
+	}
+	if jumps != nil {
+		conn, err := jumps.dial(ctx, node.Jump, addr)
+		return conn, func() {}, err
+	}
+	via, closeJumps, err := dialJumps(node.Jump, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := via.Dial("tcp", addr)
+	if err != nil {
+		closeJumps()
+		return nil, nil, err
+	}
+	return conn, closeJumps, nil
+}
+
+// jumpPool 按跳板链缓存到最后一跳的连接，反复检查经过跳板的节点时不必每次重新登录跳板
+type jumpPool struct {
+	mu     sync.Mutex
+	chains map[string]*jumpChain
+	closed bool
+}
+
+// jumpChain 一条跳板链的连接，mu 只保护字段，登录跳板期间不持有
+type jumpChain struct {
+	mu     sync.Mutex
+	client *ssh.Client
+	close  func()
+	// dialing 正在进行的登录，同一跳板链同时只登录一次
+	dialing *jumpDial
+}
+
+// jumpDial 一次跳板登录，done 在登录结束后关闭
+type jumpDial struct {
+	done chan struct{}
+	err  error
+}
+
+func newJumpPool() *jumpPool {
+	return &jumpPool{chains: map[string]*jumpChain{}}
+}
+
+// dial 经过跳板链连接 addr，跳板连接断开时重新登录一次
+func (p *jumpPool) dial(ctx context.Context, jumps []string, addr string) (net.Conn, error) {
+	key := strings.Join(jumps, ",")
+	p.mu.Lock()
+	chain := p.chains[key]
+	if chain == nil {
+		chain = &jumpChain{}
+		p.chains[key] = chain
+	}
+	p.mu.Unlock()
+
+	for retry := 0; ; retry++ {
+		client, err := chain.get(ctx, p, jumps)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := client.DialContext(ctx, "tcp", addr)
+		var openErr *ssh.OpenChannelError
+		if err == nil || errors.As(err, &openErr) || ctx.Err() != nil || retry > 0 {
+			// 跳板拒绝打开通道说明目标不可达，跳板连接本身仍然可用
+			return conn, err
+		}
+		chain.reset(client)
+	}
+}
+
+// get 返回跳板链的连接，没有时登录跳板；其他检查正在登录时等待其结果或 ctx 结束
+func (c *jumpChain) get(ctx context.Context, p *jumpPool, jumps []string) (*ssh.Client, error) {
+	c.mu.Lock()
+	if c.client != nil {
+		client := c.client
+		c.mu.Unlock()
+		return client, nil
+	}
+	if d := c.dialing; d != nil {
+		c.mu.Unlock()
+		select {
+		case <-d.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.client == nil {
+			return nil, d.err
+		}
+		return c.client, nil
+	}
+	d := &jumpDial{done: make(chan struct{})}
+	c.dialing = d
+	c.mu.Unlock()
+
+	client, closeJumps, err := dialJumps(jumps, true)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil && p.isClosed() {
+		// 登录期间 Close 已经执行，不再缓存新的连接
+		closeJumps()
+		client, err = nil, net.ErrClosed
+	}
+	if err == nil {
+		c.client, c.close = client, closeJumps
+	}
+	d.err = err
+	c.dialing = nil
+	close(d.done)
+	return client, err
+}
+
+// reset 关闭已经不可用的跳板连接，下次使用时重新登录
+func (c *jumpChain) reset(broken *ssh.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil && c.client == broken {
+		c.close()
+		c.client, c.close = nil, nil
+	}
+}
+
+// isClosed 返回 Close 是否已经执行
+func (p *jumpPool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// Close 关闭所有跳板连接，之后不再建立新的跳板连接
+// 不等待正在进行的登录，登录结束后其连接由登录的一方关闭
+func (p *jumpPool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	chains := make([]*jumpChain, 0, len(p.chains))
+	for _, chain := range p.chains {
+		chains = append(chains, chain)
+	}
+	p.mu.Unlock()
+	for _, chain := range chains {
+		chain.mu.Lock()
+		if chain.client != nil {
+			chain.close()
+			chain.client, chain.close = nil, nil
+		}
+		chain.mu.Unlock()
+	}
+}
+
+// versionConn 记录服务器在握手开始时发送的版本字符串
+type versionConn struct {
+	net.Conn
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (v *versionConn) Read(p []byte) (int, error) {
+	n, err := v.Conn.Read(p)
+	v.mu.Lock()
+	// 版本行不超过 255 字节，之前可能有几行欢迎信息
+	if len(v.buf) < 4096 {
+		v.buf = append(v.buf, p[:n]...)
+	}
+	v.mu.Unlock()
+	return n, err
+}
+
+// version 返回服务器的版本字符串，如 SSH-2.0-OpenSSH_9.6
+func (v *versionConn) version() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, line := range bytes.Split(v.buf, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("SSH-")) {
+			return strings.TrimRight(string(line), "\r")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+package ssh
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"mysshw/config"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestProbeServer 启动只接受密码 secret 的 SSH 服务端，返回端口
+func newTestProbeServer(t *testing.T) int {
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) == "secret" {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+		ServerVersion: "SSH-2.0-ProbeTest",
+	}
+	serverConfig.AddHostKey(newTestSigner(t))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				conn, chans, reqs, err := ssh.NewServerConn(c, serverConfig)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				go func() {
+					for nc := range chans {
+						nc.Reject(ssh.Prohibited, "probe test")
+					}
+				}()
+				conn.Wait()
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestProbeJumpPool(t *testing.T) {
+	port := newTestProbeServer(t)
+	bastion := &config.SSHNode{Name: "bastion", Host: "127.0.0.1", Port: port, User: "u", Password: "secret", StrictHostKey: config.HostKeyOff}
+	inner := &config.SSHNode{Name: "inner", Host: "10.0.0.1", Jump: config.StringList{"bastion"}}
+	defer func(cfg *config.Configs) { config.CFG = cfg }(config.CFG)
+	config.CFG = &config.Configs{Nodes: []config.Nodes{{Groups: "g", SSHNodes: []*config.SSHNode{bastion, inner}}}}
+
+	jumps := newJumpPool()
+	ctx := context.Background()
+	// 测试服务端拒绝所有通道，跳板连接仍然保留并在下一次检查时复用
+	r := probeVia(ctx, inner, ProbeTCP, 5*time.Second, jumps)
+	assert.False(t, r.Reachable)
+	assert.Contains(t, r.Error, "probe test")
+	client := jumps.chains["bastion"].client
+	assert.NotNil(t, client)
+	probeVia(ctx, inner, ProbeTCP, 5*time.Second, jumps)
+	assert.Same(t, client, jumps.chains["bastion"].client)
+
+	// 关闭后不再保留跳板连接
+	jumps.Close()
+	assert.Nil(t, jumps.chains["bastion"].client)
+	r = probeVia(ctx, inner, ProbeTCP, 5*time.Second, jumps)
+	assert.False(t, r.Reachable)
+
+	// 跳板接受连接但不握手时，Close 不等待正在进行的登录
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer silent.Close()
+	go func() {
+		for {
+			c, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			time.AfterFunc(500*time.Millisecond, func() { c.Close() })
+		}
+	}()
+	bastion.Port = silent.Addr().(*net.TCPAddr).Port
+	jumps = newJumpPool()
+	r = probeVia(ctx, inner, ProbeTCP, 100*time.Millisecond, jumps)
+	assert.True(t, r.TimedOut)
+	start := time.Now()
+	jumps.Close()
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	// 等待登录失败后再恢复 config.CFG
+	chain := jumps.chains["bastion"]
+	assert.Eventually(t, func() bool {
+		chain.mu.Lock()
+		defer chain.mu.Unlock()
+		return chain.dialing == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, chain.client)
+}
+
+func TestProbe(t *testing.T) {
+	port := newTestProbeServer(t)
+	node := func(password string) *config.SSHNode {
+		return &config.SSHNode{Name: "n", Host: "127.0.0.1", Port: port, User: "u", Password: password, StrictHostKey: config.HostKeyOff}
+	}
+	ctx := context.Background()
+
+	r := Probe(ctx, node("secret"), ProbeAuth, 5*time.Second)
+	assert.True(t, r.OK(ProbeAuth), r.Error)
+	assert.Equal(t, "SSH-2.0-ProbeTest", r.ServerVersion)
+	assert.Equal(t, ProbeAuthOK, r.Auth)
+
+	// 只检查握手时不需要认证
+	r = Probe(ctx, node("wrong"), ProbeHandshake, 5*time.Second)
+	assert.True(t, r.OK(ProbeHandshake), r.Error)
+	assert.Empty(t, r.Auth)
+
+	r = Probe(ctx, node("wrong"), ProbeAuth, 5*time.Second)
+	assert.False(t, r.OK(ProbeAuth))
+	assert.True(t, r.Handshake)
+	assert.Equal(t, ProbeAuthFailed, r.Auth)
+
+	// 端口关闭
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	closed := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	r = Probe(ctx, &config.SSHNode{Name: "closed", Host: "127.0.0.1", Port: closed}, ProbeTCP, 5*time.Second)
+	assert.False(t, r.Reachable)
+	assert.NotEmpty(t, r.Error)
+
+	// 接受连接但不发送版本号的服务端
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer silent.Close()
+	go func() {
+		for {
+			c, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+	silentPort := silent.Addr().(*net.TCPAddr).Port
+	start := time.Now()
+	r = Probe(ctx, &config.SSHNode{Name: "silent", Host: "127.0.0.1", Port: silentPort, StrictHostKey: config.HostKeyOff}, ProbeHandshake, 200*time.Millisecond)
+	assert.True(t, r.TimedOut)
+	assert.False(t, r.OK(ProbeHandshake))
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,100 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/muesli/cancelreader"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// reconnectMaxAttempts 自动重连的最大尝试次数
+	reconnectMaxAttempts = 10
+	// reconnectMaxDelay 自动重连的最大等待间隔
+	reconnectMaxDelay = 30 * time.Second
+	// transportCloseWait 会话没有退出状态就结束时，等待连接断开(client.Wait 返回)的时间
+	transportCloseWait = time.Second
+)
+
+// sessionLost 根据 session.Wait 的返回值判断会话是否因连接断开而结束
+// 正常退出(包括非0退出码)时远程会发送退出状态，连接断开时没有；
+// 没有退出状态时还要求连接确实已经断开: 本地关闭会话(终端输入结束)不算，
+// transportDone 在 client.Wait 返回后关闭，inputClosed 在本地因输入结束关闭会话后关闭
+func sessionLost(err error, inputClosed, transportDone <-chan struct{}) bool {
+	if err == nil {
+		return false
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return false
+	}
+	select {
+	case <-inputClosed:
+		return false
+	default:
+	}
+	select {
+	case <-transportDone:
+		return true
+	case <-time.After(transportCloseWait):
+		return false
+	}
+}
+
+// reconnect 按指数退避重新连接节点，全部失败时返回nil
+func (c *defaultClient) reconnect() *ssh.Client {
+	delay := time.Second
+	for attempt := 1; attempt <= reconnectMaxAttempts; attempt++ {
+		fmt.Printf(SSHReconnectingStr, c.node.Host, attempt, reconnectMaxAttempts, delay)
+		time.Sleep(delay)
+		client, err := c.connect()
+		if err == nil {
+			fmt.Printf(SSHReconnectedStr, c.node.Host)
+			return client
+		}
+		fmt.Printf(SSHReconnectFailedStr, err)
+		delay = min(delay*2, reconnectMaxDelay)
+	}
+	fmt.Printf(SSHReconnectGiveUpStr, c.node.Host, reconnectMaxAttempts)
+	return nil
+}
+
+// attachTerminalInput 将终端输入转发到会话，返回的函数停止读取终端并等待读取的 goroutine 退出
+// 读取可以取消，会话结束后不会留下继续读取 os.Stdin 的 goroutine 与之后的新会话、
+// 节点菜单或密码与确认提示争抢输入；终端输入结束(EOF)时关闭会话并关闭 inputClosed
+func attachTerminalInput(session *ssh.Session, stdin io.Writer) (detach func(), inputClosed <-chan struct{}, err error) {
+	reader, err := cancelreader.NewReader(os.Stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := reader.Read(buf)
+			if n > 0 {
+				stdin.Write(buf[:n])
+			}
+			if err != nil {
+				if !errors.Is(err, cancelreader.ErrCanceled) {
+					close(closed)
+					session.Close()
+				}
+				return
+			}
+		}
+	}()
+	return func() {
+		// stdin 不是终端等无法取消的情况下 Cancel 返回false，此时读取在下一次输入或 EOF 后才结束
+		if reader.Cancel() {
+			<-done
+		}
+		reader.Close()
+	}, closed, nil
+}
@@ -0,0 +1,23 @@
+package ssh
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionLost(t *testing.T) {
+	closed := make(chan struct{})
+	close(closed)
+	open := make(chan struct{})
+	errNoExit := errors.New("wait: remote command exited without exit status or exit signal")
+
+	assert.False(t, sessionLost(nil, open, closed))
+	// 连接已经断开
+	assert.True(t, sessionLost(errNoExit, open, closed))
+	// 终端输入结束后本地关闭了会话
+	assert.False(t, sessionLost(errNoExit, closed, closed))
+	// 连接仍然正常
+	assert.False(t, sessionLost(errNoExit, open, open))
+}
@@ -0,0 +1,191 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"mysshw/config"
+)
+
+// castHeader asciicast v2 文件头
+// see: https://docs.asciinema.org/manual/asciicast/v2/
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// castRecorder 将终端输出与窗口大小变化按 asciicast v2 格式写入文件
+type castRecorder struct {
+	mu      sync.Mutex
+	f       *os.File
+	w       *bufio.Writer
+	start   time.Time
+	pending []byte // 被拆开的不完整 UTF-8 字符，等下一次写入补齐
+	path    string
+}
+
+// unsafeFileChars 录制文件名中不允许出现的字符
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// newCastRecorder 在 dir 下创建录制文件，文件名为 <节点名>-<时间>.cast
+func newCastRecorder(dir string, node *config.SSHNode, width, height int) (*castRecorder, error) {
+	dir, err := expandHomeDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	now := time.Now()
+	name := unsafeFileChars.ReplaceAllString(node.Name, "_")
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.cast", name, now.Format("20060102-150405")))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+
+	r := &castRecorder{f: f, w: bufio.NewWriter(f), start: now, path: path}
+	header := castHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: now.Unix(),
+		Title:     fmt.Sprintf("%s (%s@%s)", node.Name, node.SetUser(), node.Host),
+		Env:       map[string]string{"TERM": "xterm", "SHELL": os.Getenv("SHELL")},
+	}
+	b, _ := json.Marshal(header)
+	r.w.Write(b)
+	r.w.WriteByte('\n')
+	return r, nil
+}
+
+// Write 实现 io.Writer，记录一条输出("o")事件
+func (r *castRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data := append(r.pending, p...)
+	// 只写入完整的 UTF-8 字符，末尾被截断的字节留到下一次
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		r.event("o", string(data[:cut]))
+	}
+	return len(p), nil
+}
+
+// Resize 记录一条窗口大小变化("r")事件
+func (r *castRecorder) Resize(width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// event 写入一行事件: [时间(秒), 类型, 数据]
+func (r *castRecorder) event(kind, data string) {
+	b, _ := json.Marshal([]interface{}{time.Since(r.start).Seconds(), kind, data})
+	r.w.Write(b)
+	r.w.WriteByte('\n')
+}
+
+// Close 写入剩余数据并关闭录制文件
+func (r *castRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) > 0 {
+		r.event("o", string(r.pending))
+		r.pending = nil
+	}
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
+
+// Replay 按录制时的节奏在 out 中回放 asciicast v2 文件
+// speed 为回放速度倍数；idleLimit 大于0时，两次输出之间的停顿最多 idleLimit
+func Replay(ctx context.Context, path string, out io.Writer, speed float64, idleLimit time.Duration) error {
+	if speed <= 0 {
+		return fmt.Errorf("speed must be greater than 0")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		return fmt.Errorf("%s: empty recording", path)
+	}
+	var header castHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return fmt.Errorf("%s: invalid asciicast header: %w", path, err)
+	}
+	if header.Version != 2 {
+		return fmt.Errorf("%s: unsupported asciicast version %d", path, header.Version)
+	}
+
+	var last float64
+	for line := 2; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var ev []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil || len(ev) != 3 {
+			return fmt.Errorf("%s:%d: invalid event", path, line)
+		}
+		at, ok1 := ev[0].(float64)
+		kind, ok2 := ev[1].(string)
+		data, ok3 := ev[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			return fmt.Errorf("%s:%d: invalid event", path, line)
+		}
+		// 只回放输出事件，跳过输入与窗口变化事件
+		if kind != "o" {
+			continue
+		}
+
+		delay := time.Duration((at - last) / speed * float64(time.Second))
+		if idleLimit > 0 && delay > idleLimit {
+			delay = idleLimit
+		}
+		last = at
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		if _, err := io.WriteString(out, data); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
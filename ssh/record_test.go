@@ -0,0 +1,57 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mysshw/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCastRecorderReplay(t *testing.T) {
+	dir := t.TempDir()
+	node := &config.SSHNode{Name: "prod/db 1", Host: "10.0.0.5", User: "root"}
+
+	r, err := newCastRecorder(dir, node, 80, 24)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(r.path, dir))
+	assert.Contains(t, r.path, "prod_db_1-")
+
+	r.Write([]byte("hello "))
+	// 多字节字符被拆成两次写入
+	r.Write([]byte("世界"[:2]))
+	r.Write([]byte("世界"[2:] + "\r\n"))
+	r.Resize(100, 30)
+	assert.NoError(t, r.Close())
+
+	content, err := os.ReadFile(r.path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if assert.Len(t, lines, 4) {
+		var header castHeader
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+		assert.Equal(t, 2, header.Version)
+		assert.Equal(t, 80, header.Width)
+		assert.Equal(t, 24, header.Height)
+
+		var ev []interface{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[2]), &ev))
+		assert.Equal(t, "o", ev[1])
+		assert.Equal(t, "世界\r\n", ev[2])
+		assert.NoError(t, json.Unmarshal([]byte(lines[3]), &ev))
+		assert.Equal(t, []interface{}{"r", "100x30"}, ev[1:])
+	}
+
+	var out bytes.Buffer
+	err = Replay(context.Background(), r.path, &out, 10, time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello 世界\r\n", out.String())
+
+	assert.Error(t, Replay(context.Background(), r.path, &out, 0, 0))
+}
@@ -0,0 +1,197 @@
+package ssh
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+)
+
+// SOCKS5 协议常量(RFC 1928)
+const (
+	socksVersion5       = 0x05
+	socksAuthNone       = 0x00
+	socksAuthNoAccept   = 0xff
+	socksCmdConnect     = 0x01
+	socksAtypIPv4       = 0x01
+	socksAtypDomain     = 0x03
+	socksAtypIPv6       = 0x04
+	socksRepSuccess     = 0x00
+	socksRepHostUnreach = 0x04
+	socksRepCmdNotSupp  = 0x07
+	socksRepAtypNotSupp = 0x08
+)
+
+// Socks 建立SSH连接并在本地 listen 地址运行 SOCKS5 代理，直到ctx结束或连接断开
+// 每个 CONNECT 请求都通过节点的 ssh.Client.Dial 建立；localDNS 为 true 时在本地解析域名，
+// 否则把域名交给节点解析(相当于 socks5h)
+func (c *defaultClient) Socks(ctx context.Context, listen string, localDNS bool) error {
+	if c == nil {
+		return fmt.Errorf("invalid SSH node configuration")
+	}
+	client, err := c.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	fmt.Printf(SSHConnectInfoStr, c.node.SetPort(), c.node.SetUser(), c.node.Host, string(client.ServerVersion()))
+
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("socks5 listen %s: %w", listen, err)
+	}
+	defer ln.Close()
+	fmt.Printf(SocksListenInfoStr, ln.Addr(), c.node.Name)
+
+	server := &socksServer{dial: client.Dial, localDNS: localDNS}
+	go server.serve(ln)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	dead := c.startKeepalive(ctx, client)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Wait()
+	}()
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-done:
+		select {
+		case <-dead:
+			return fmt.Errorf("connection to %s timed out", c.node.Host)
+		default:
+		}
+		return fmt.Errorf("connection to %s closed: %v", c.node.Host, err)
+	}
+}
+
+// socksServer 最小化的 SOCKS5 服务端，只支持无认证的 CONNECT 命令
+type socksServer struct {
+	dial     func(network, addr string) (net.Conn, error)
+	localDNS bool
+}
+
+// serve 接受连接直到监听关闭
+func (s *socksServer) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle 处理单个 SOCKS5 客户端连接
+func (s *socksServer) handle(conn net.Conn) {
+	target, err := s.handshake(conn)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	remote, err := s.dial("tcp", target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, ForwardDialErrStr, target, err)
+		writeSocksReply(conn, socksRepHostUnreach)
+		conn.Close()
+		return
+	}
+	if err := writeSocksReply(conn, socksRepSuccess); err != nil {
+		remote.Close()
+		conn.Close()
+		return
+	}
+	relay(conn, remote)
+}
+
+// handshake 完成方法协商并读取 CONNECT 请求，返回目标地址 host:port
+func (s *socksServer) handshake(conn net.Conn) (string, error) {
+	// 方法协商: VER NMETHODS METHODS
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != socksVersion5 {
+		return "", fmt.Errorf("unsupported socks version %d", header[0])
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+	method := byte(socksAuthNoAccept)
+	for _, m := range methods {
+		if m == socksAuthNone {
+			method = socksAuthNone
+			break
+		}
+	}
+	if _, err := conn.Write([]byte{socksVersion5, method}); err != nil {
+		return "", err
+	}
+	if method == socksAuthNoAccept {
+		return "", errors.New("no acceptable socks auth method")
+	}
+
+	// 请求: VER CMD RSV ATYP DST.ADDR DST.PORT
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return "", err
+	}
+	if req[1] != socksCmdConnect {
+		writeSocksReply(conn, socksRepCmdNotSupp)
+		return "", fmt.Errorf("unsupported socks command %d", req[1])
+	}
+
+	var host string
+	switch req[3] {
+	case socksAtypIPv4, socksAtypIPv6:
+		size := net.IPv4len
+		if req[3] == socksAtypIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case socksAtypDomain:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return "", err
+		}
+		domain := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+		if s.localDNS {
+			addrs, err := net.LookupHost(host)
+			if err != nil || len(addrs) == 0 {
+				writeSocksReply(conn, socksRepHostUnreach)
+				return "", fmt.Errorf("resolve %s: %v", host, err)
+			}
+			host = addrs[0]
+		}
+	default:
+		writeSocksReply(conn, socksRepAtypNotSupp)
+		return "", fmt.Errorf("unsupported socks address type %d", req[3])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// writeSocksReply 写入应答，经SSH转发时本地绑定地址无意义，统一返回 0.0.0.0:0
+func writeSocksReply(w io.Writer, rep byte) error {
+	_, err := w.Write([]byte{socksVersion5, rep, 0x00, socksAtypIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,85 @@
+package ssh
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSocksHandshake(t *testing.T) {
+	testCases := []struct {
+		name     string
+		request  []byte
+		localDNS bool
+		target   string
+		hasErr   bool
+	}{{
+		name:    "IPv4",
+		request: []byte{0x05, 0x01, 0x00, 0x01, 10, 0, 0, 5, 0x1f, 0x90},
+		target:  "10.0.0.5:8080",
+	}, {
+		name:    "域名交给远端解析",
+		request: append(append([]byte{0x05, 0x01, 0x00, 0x03, 11}, "db.internal"...), 0x0c, 0x38),
+		target:  "db.internal:3128",
+	}, {
+		name:     "域名本地解析",
+		request:  append(append([]byte{0x05, 0x01, 0x00, 0x03, 9}, "localhost"...), 0x00, 0x50),
+		localDNS: true,
+		target:   "127.0.0.1:80",
+	}, {
+		name:    "IPv6",
+		request: []byte{0x05, 0x01, 0x00, 0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0x00, 0x16},
+		target:  "[::1]:22",
+	}, {
+		name:    "不支持 BIND 命令",
+		request: []byte{0x05, 0x02, 0x00, 0x01, 10, 0, 0, 5, 0x1f, 0x90},
+		hasErr:  true,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+
+			s := &socksServer{localDNS: tc.localDNS}
+			type result struct {
+				target string
+				err    error
+			}
+			done := make(chan result, 1)
+			go func() {
+				target, err := s.handshake(server)
+				server.Close()
+				done <- result{target, err}
+			}()
+
+			// 方法协商，客户端只支持无认证
+			_, err := client.Write([]byte{0x05, 0x01, 0x00})
+			assert.NoError(t, err)
+			reply := make([]byte, 2)
+			_, err = io.ReadFull(client, reply)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{0x05, 0x00}, reply)
+
+			// net.Pipe 是同步的，服务端可能在请求未读完时就写入应答
+			go io.Copy(io.Discard, client)
+			go client.Write(tc.request)
+
+			r := <-done
+			if tc.hasErr {
+				assert.Error(t, r.err)
+				return
+			}
+			assert.NoError(t, r.err)
+			if tc.localDNS {
+				// 本地解析结果取决于系统，只要求已解析为IP
+				host, _, _ := net.SplitHostPort(r.target)
+				assert.NotNil(t, net.ParseIP(host))
+				return
+			}
+			assert.Equal(t, tc.target, r.target)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// maxHistory 最多保存的连接记录数，超出时丢弃最早的记录
+const maxHistory = 1000
+
+// Entry 一次连接记录
+type Entry struct {
+	Node  string    `json:"node"`
+	Group string    `json:"group,omitempty"`
+	User  string    `json:"user"`
+	Host  string    `json:"host"`
+	Port  int       `json:"port"`
+	Time  time.Time `json:"time"`
+	// Duration 会话持续时间
+	Duration time.Duration `json:"duration"`
+}
+
+// State 本地保存的使用状态: 连接历史与收藏的节点
+type State struct {
+	// Favorites 收藏的节点名称，按收藏顺序排列
+	Favorites []string `json:"favorites"`
+	// History 连接历史，按时间从早到晚排列
+	History []Entry `json:"history"`
+}
+
+// Path 返回状态文件路径: $XDG_STATE_HOME/mysshw/state.json，默认 ~/.local/state/mysshw/state.json
+func Path() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "mysshw", "state.json"), nil
+}
+
+// Load 读取状态文件，文件不存在时返回空状态
+func Load() (*State, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &State{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save 写入状态文件，会覆盖其他进程在 Load 之后做的修改，修改部分状态时应使用 Update
+func (s *State) Save() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockState(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write(path)
+}
+
+// Update 在锁定状态文件期间读取最新状态、调用 fn 修改并保存，返回修改后的状态
+// 多个 mysshw 进程同时记录历史或收藏节点时不会互相覆盖
+func Update(fn func(s *State)) (*State, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := lockState(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	s, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	fn(s)
+	if err := s.write(path); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Record 在连接历史中追加一条记录并保存
+func Record(e Entry) error {
+	_, err := Update(func(s *State) {
+		s.History = append(s.History, e)
+		if n := len(s.History); n > maxHistory {
+			s.History = slices.Clone(s.History[n-maxHistory:])
+		}
+	})
+	return err
+}
+
+// lockState 创建状态目录并锁定状态文件旁的 .lock 文件，返回解锁函数
+func lockState(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// write 先写同目录下的临时文件再替换，避免中断时损坏已有文件
+func (s *State) write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Recent 返回最近连接过的节点名称，按最后连接时间从近到远排列，每个节点只出现一次
+func (s *State) Recent(limit int) []string {
+	var names []string
+	for i := len(s.History) - 1; i >= 0 && len(names) < limit; i-- {
+		if name := s.History[i].Node; !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// IsFavorite 判断节点是否已收藏
+func (s *State) IsFavorite(name string) bool {
+	return slices.Contains(s.Favorites, name)
+}
+
+// ToggleFavorite 收藏或取消收藏节点，返回操作后是否为收藏状态
+func (s *State) ToggleFavorite(name string) bool {
+	if i := slices.Index(s.Favorites, name); i >= 0 {
+		s.Favorites = slices.Delete(s.Favorites, i, i+1)
+		return false
+	}
+	s.Favorites = append(s.Favorites, name)
+	return true
+}
@@ -0,0 +1,63 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", dir)
+
+	// 没有状态文件时返回空状态
+	s, err := Load()
+	assert.NoError(t, err)
+	assert.Empty(t, s.History)
+	assert.Empty(t, s.Recent(5))
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, node := range []string{"web01", "db01", "web01", "cache01"} {
+		assert.NoError(t, Record(Entry{Node: node, Host: "10.0.0.1", Time: start.Add(time.Duration(i) * time.Minute)}))
+	}
+	s, err = Load()
+	assert.NoError(t, err)
+	assert.Len(t, s.History, 4)
+	assert.Equal(t, []string{"cache01", "web01", "db01"}, s.Recent(5))
+	assert.Equal(t, []string{"cache01", "web01"}, s.Recent(2))
+
+	assert.True(t, s.ToggleFavorite("db01"))
+	assert.True(t, s.ToggleFavorite("web01"))
+	assert.False(t, s.ToggleFavorite("db01"))
+	assert.NoError(t, s.Save())
+	s, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"web01"}, s.Favorites)
+	assert.True(t, s.IsFavorite("web01"))
+
+	info, err := os.Stat(filepath.Join(dir, "mysshw", "state.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// 同时记录的历史都被保存，不留下临时文件
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, Record(Entry{Node: "web01"}))
+		}()
+	}
+	wg.Wait()
+	s, err = Load()
+	assert.NoError(t, err)
+	assert.Len(t, s.History, 24)
+	assert.Equal(t, []string{"web01"}, s.Favorites)
+	tmps, err := filepath.Glob(filepath.Join(dir, "mysshw", "*.tmp"))
+	assert.NoError(t, err)
+	assert.Empty(t, tmps)
+}